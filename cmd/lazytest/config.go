@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/meijin/lazytest/internal/config"
+	"github.com/meijin/lazytest/internal/ui"
+)
+
+// runConfig implements "lazytest config <subcommand>" and returns the exit
+// code. "check" checks the project config and, if there is one, the user
+// config.
+func runConfig(args []string) int {
+	if len(args) == 0 || args[0] != "check" {
+		fmt.Fprintln(os.Stderr, "usage: lazytest config check [-config path]")
+		return 2
+	}
+
+	fs := flag.NewFlagSet("config check", flag.ExitOnError)
+	configPath := fs.String("config", "", "path to .lazytest.yml config file (default: the project's)")
+	fs.Parse(args[1:])
+	if *configPath == "" {
+		enterProjectRoot()
+		*configPath = config.ConfigFileName
+	}
+
+	ok := checkConfig(*configPath, checkProject)
+	// The user config is optional; check it only when there is one.
+	if userPath, err := config.UserConfigPath(); err == nil {
+		if _, err := os.Stat(userPath); err == nil {
+			ok = checkConfig(userPath, config.CheckUser) && ok
+		}
+	}
+	if !ok {
+		return 1
+	}
+	return 0
+}
+
+// checkProject checks the project config at path, and warns about actions
+// whose key the results view already binds, with the user's remaps.
+func checkProject(path string) ([]config.Problem, error) {
+	problems, err := config.Check(path)
+	if err != nil {
+		return nil, err
+	}
+	// A broken user config is reported on its own; assume the default keys.
+	user, _ := config.LoadUser()
+	clashes, err := config.CheckActionKeys(path, ui.ResultsBindings(user.Keys))
+	if err != nil {
+		return nil, err
+	}
+	problems = append(problems, clashes...)
+	config.SortProblems(problems)
+	return problems, nil
+}
+
+// checkConfig prints the problems check finds in the config at path, or
+// that it is OK, and reports whether it is. Warnings alone don't fail it.
+func checkConfig(path string, check func(string) ([]config.Problem, error)) bool {
+	problems, err := check(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading config: %v\n", err)
+		return false
+	}
+	if len(problems) == 0 {
+		fmt.Printf("%s: OK\n", path)
+		return true
+	}
+	fmt.Println((&config.Error{Path: path, Problems: problems}).Error())
+	warnings := 0
+	for _, p := range problems {
+		if p.Warning {
+			warnings++
+		}
+	}
+	if errs := len(problems) - warnings; errs > 0 {
+		fmt.Fprintf(os.Stderr, "%d problem(s) and %d warning(s) found in %s\n", errs, warnings, path)
+		return false
+	}
+	fmt.Fprintf(os.Stderr, "%d warning(s) found in %s\n", warnings, path)
+	return true
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/meijin/lazytest/internal/config"
+	"github.com/meijin/lazytest/internal/state"
+)
+
+// runInit implements "lazytest init": it proposes the auto-detected targets,
+// lets the user accept, rename or edit each one and writes them to a new
+// config file, or appends the packages it doesn't cover yet to an existing
+// one. It returns the exit code.
+func runInit(args []string) int {
+	fs := flag.NewFlagSet("init", flag.ExitOnError)
+	configPath := fs.String("config", config.ConfigFileName, "path to .lazytest.yml config file")
+	yes := fs.Bool("y", false, "accept all detected targets without asking")
+	fs.Parse(args)
+
+	detected, err := config.DetectFrameworks(".")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error detecting frameworks: %v\n", err)
+		return 1
+	}
+	config.QueryFrameworks(".", detected)
+
+	existing, err := os.ReadFile(*configPath)
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "Error reading config: %v\n", err)
+		return 1
+	}
+	candidates := detected
+	var taken []string
+	if existing != nil {
+		if candidates, taken, err = config.Unconfigured(*configPath, detected); err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading config: %s: %v\n", *configPath, err)
+			return 1
+		}
+		if len(candidates) == 0 {
+			fmt.Printf("%s already has a target for every detected package\n", *configPath)
+			return 0
+		}
+		fmt.Printf("Found %d package(s) not in %s yet.\n\n", len(candidates), *configPath)
+	} else {
+		fmt.Printf("Detected %d target(s).\n\n", len(candidates))
+	}
+
+	accepted := candidates
+	if !*yes {
+		p := prompter{in: bufio.NewReader(os.Stdin), out: os.Stdout}
+		accepted = p.reviewTargets(candidates, taken)
+	}
+	if len(accepted) == 0 {
+		fmt.Println("No targets accepted; nothing written")
+		return 0
+	}
+
+	var data []byte
+	if existing != nil {
+		data, err = config.AppendTargets(existing, accepted)
+	} else {
+		data, err = config.Generate(accepted)
+	}
+	if err == nil {
+		err = state.WriteFileAtomic(*configPath, data)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing config: %v\n", err)
+		return 1
+	}
+
+	if existing != nil {
+		fmt.Printf("Added %d target(s) to %s\n", len(accepted), *configPath)
+	} else {
+		fmt.Printf("Wrote %d target(s) to %s\n", len(accepted), *configPath)
+	}
+	return 0
+}
+
+// prompter asks questions on a line-based terminal. Once input ends every
+// question gets its default answer.
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+	eof bool
+}
+
+// ask prints question with its default and returns the trimmed answer, or
+// def if the answer is empty.
+func (p *prompter) ask(question, def string) string {
+	if def != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", question)
+	}
+	if p.eof {
+		fmt.Fprintln(p.out)
+		return def
+	}
+	line, err := p.in.ReadString('\n')
+	if err != nil {
+		p.eof = true
+		if line == "" {
+			fmt.Fprintln(p.out)
+		}
+	}
+	if answer := strings.TrimSpace(line); answer != "" {
+		return answer
+	}
+	return def
+}
+
+// reviewTargets walks through targets, asking whether to add, skip or edit
+// each one, and returns the ones to add. Names in taken are already used by
+// the config.
+func (p *prompter) reviewTargets(targets []config.Target, taken []string) []config.Target {
+	var accepted []config.Target
+	names := make(map[string]bool)
+	for _, name := range taken {
+		names[name] = true
+	}
+	for i, t := range targets {
+		for {
+			fmt.Fprintf(p.out, "[%d/%d] ", i+1, len(targets))
+			printTarget(p.out, t)
+
+			answer := strings.ToLower(p.ask("Add this target? (y)es, (n)o, (e)dit", "y"))
+			switch {
+			case strings.HasPrefix(answer, "y"):
+				if names[t.Name] {
+					// Without input the name can't be edited any more.
+					if p.eof {
+						fmt.Fprintf(p.out, "  A target named %q already exists; skipping it.\n", t.Name)
+						break
+					}
+					fmt.Fprintf(p.out, "  A target named %q already exists; edit it to pick another name.\n\n", t.Name)
+					continue
+				}
+				names[t.Name] = true
+				accepted = append(accepted, t)
+			case strings.HasPrefix(answer, "n"):
+			case strings.HasPrefix(answer, "e"):
+				t = p.editTarget(t)
+				fmt.Fprintln(p.out)
+				continue
+			default:
+				continue
+			}
+			fmt.Fprintln(p.out)
+			break
+		}
+	}
+	return accepted
+}
+
+// editTarget asks for a new value of each editable field of t.
+func (p *prompter) editTarget(t config.Target) config.Target {
+	t.Name = p.ask("  name", t.Name)
+	t.TestDirs = splitList(p.ask("  test_dirs (comma-separated)", strings.Join(t.TestDirs, ",")))
+	t.FilePattern = p.ask("  file_pattern", t.FilePattern)
+	t.Command = p.ask("  command", t.Command)
+	t.WorkingDir = p.ask("  working_dir", t.WorkingDir)
+	return t
+}
+
+func printTarget(w io.Writer, t config.Target) {
+	fmt.Fprintf(w, "%s (%s)\n", t.Name, t.Type)
+	fmt.Fprintf(w, "    command:      %s\n", t.Command)
+	fmt.Fprintf(w, "    test_dirs:    %s\n", strings.Join(t.TestDirs, ", "))
+	fmt.Fprintf(w, "    file_pattern: %s\n", t.FilePattern)
+	if t.WorkingDir != "" {
+		fmt.Fprintf(w, "    working_dir:  %s\n", t.WorkingDir)
+	}
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
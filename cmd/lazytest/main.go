@@ -4,24 +4,59 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/meijin/lazytest/internal/config"
 	"github.com/meijin/lazytest/internal/discovery"
+	"github.com/meijin/lazytest/internal/domain"
+	"github.com/meijin/lazytest/internal/state"
 	"github.com/meijin/lazytest/internal/ui"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "config":
+			os.Exit(runConfig(os.Args[2:]))
+		case "init":
+			os.Exit(runInit(os.Args[2:]))
+		case "run":
+			os.Exit(runRun(os.Args[2:]))
+		}
+	}
+
 	configPath := flag.String("config", "", "path to .lazytest.yml config file")
 	flag.Parse()
 
+	// Without an explicit config, work from the project root so lazytest
+	// can be started anywhere inside the project.
+	var launchDir string
+	if *configPath == "" {
+		launchDir = enterProjectRoot()
+	}
+
 	cfg, err := config.Load(*configPath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
 		os.Exit(1)
 	}
+	user, err := config.LoadUser()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading user config: %v\n", err)
+		os.Exit(1)
+	}
+	cfg.Options = cfg.Options.Merge(user.Options)
+	ui.Configure(cfg.Options, user)
+
+	// Run history and the file index are best-effort; without a state dir
+	// frecency stays in memory and every start does a full scan.
+	stateDir, err := state.Dir(".")
+	if err != nil {
+		stateDir = ""
+	}
 
-	files, err := discovery.ScanAllTargets(cfg.Targets)
+	files, cached, err := loadFiles(stateDir, cfg.Targets)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error scanning test files: %v\n", err)
 		os.Exit(1)
@@ -32,11 +67,53 @@ func main() {
 		os.Exit(1)
 	}
 
-	app := ui.NewApp(cfg, files)
+	app := ui.NewApp(cfg, files, stateDir)
+	if cached {
+		app = app.WithStartupRescan()
+	}
+	if launchDir != "" {
+		app = app.WithLaunchDir(launchDir)
+	}
 	p := tea.NewProgram(app, tea.WithAltScreen())
 
+	// Without a config file, ask Vitest and Jest which tests they run while
+	// the UI is up; the answer is cached for the next start's detection.
+	if _, err := os.Stat(config.ConfigFileName); *configPath == "" && os.IsNotExist(err) {
+		go config.QueryFrameworks(".", append([]config.Target(nil), cfg.Targets...))
+	}
+
 	if _, err := p.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
+
+// loadFiles returns the test files of targets: from the index when it is
+// up to date, otherwise from a full scan. cached reports the former.
+func loadFiles(stateDir string, targets []config.Target) (files []domain.TestFile, cached bool, err error) {
+	files, cached = discovery.LoadIndex(stateDir, targets)
+	if !cached {
+		files, err = discovery.ScanAndIndex(stateDir, targets)
+	}
+	return files, cached, err
+}
+
+// enterProjectRoot changes to the project root containing the current
+// directory and returns the directory lazytest was started in, relative to
+// the root. It returns "" and stays put when started in the root or outside
+// any project.
+func enterProjectRoot() string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	root, err := config.FindProjectRoot(cwd)
+	if err != nil || root == cwd {
+		return ""
+	}
+	rel, err := filepath.Rel(root, cwd)
+	if err != nil || os.Chdir(root) != nil {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/meijin/lazytest/internal/config"
+	"github.com/meijin/lazytest/internal/domain"
+	"github.com/meijin/lazytest/internal/state"
+	"github.com/meijin/lazytest/internal/ui"
+)
+
+// runRun implements "lazytest run --set <name>": it runs a test set without
+// the UI and returns the exit code, 1 when a test failed or a target could
+// not run.
+func runRun(args []string) int {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	configPath := fs.String("config", "", "path to .lazytest.yml config file (default: the project's)")
+	setName := fs.String("set", "", "name of the test set to run")
+	fs.Parse(args)
+	if *setName == "" || fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "usage: lazytest run --set <name> [-config path]")
+		return 2
+	}
+	if *configPath == "" {
+		enterProjectRoot()
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		return 1
+	}
+	user, err := config.LoadUser()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading user config: %v\n", err)
+		return 1
+	}
+	cfg.Options = cfg.Options.Merge(user.Options)
+
+	stateDir, err := state.Dir(".")
+	if err != nil {
+		stateDir = ""
+	}
+	var saved []domain.TestSet
+	if stateDir != "" {
+		if saved, err = state.LoadSets(stateDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading saved test sets: %v\n", err)
+			return 1
+		}
+	}
+	set, ok := findSet(*setName, saved, cfg.Sets)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown test set %q%s\n", *setName, setNames(saved, cfg.Sets))
+		return 1
+	}
+
+	files, _, err := loadFiles(stateDir, cfg.Targets)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error scanning test files: %v\n", err)
+		return 1
+	}
+	files, missing := ui.ResolveSet(set, files)
+	if missing > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %d file(s) of set %q no longer exist\n", missing, set.Name)
+	}
+	if len(files) == 0 {
+		fmt.Fprintf(os.Stderr, "Test set %q has no test files\n", set.Name)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	fmt.Printf("Running test set %q: %d file(s)\n\n", set.Name, len(files))
+	if !ui.RunHeadless(ctx, cfg, files, os.Stdout) {
+		return 1
+	}
+	return 0
+}
+
+// findSet looks name up in the saved sets, then in the project's, the same
+// precedence the set picker uses.
+func findSet(name string, saved, project []domain.TestSet) (domain.TestSet, bool) {
+	for _, sets := range [][]domain.TestSet{saved, project} {
+		for _, s := range sets {
+			if s.Name == name {
+				return s, true
+			}
+		}
+	}
+	return domain.TestSet{}, false
+}
+
+// setNames lists the known set names for an error message.
+func setNames(saved, project []domain.TestSet) string {
+	var names []string
+	for _, sets := range [][]domain.TestSet{saved, project} {
+		for _, s := range sets {
+			names = append(names, s.Name)
+		}
+	}
+	if len(names) == 0 {
+		return "; no test sets are defined"
+	}
+	return " (known: " + strings.Join(names, ", ") + ")"
+}
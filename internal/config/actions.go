@@ -0,0 +1,172 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ActionScopes lists what an action can run on: the file or the test under
+// the cursor in the results view, every file of the cursor's target, or the
+// files of the last run.
+var ActionScopes = []string{"file", "test", "target", "run"}
+
+// Action is a custom command bound to a key in the results view, e.g.
+// "update snapshots" running "npx vitest run -u {files}". It runs like a
+// test run on the files of its scope, with Command in place of the
+// targets' commands. Other modes don't run actions: they read keys as
+// query text or have no cursor to act on.
+type Action struct {
+	Key   string `yaml:"key"`
+	Label string `yaml:"label"`
+	// Scope is one of ActionScopes; the default is "run".
+	Scope string `yaml:"scope"`
+	// Command takes the placeholders of a target command, and {test}, the
+	// quoted name of the test under the cursor, for the "test" scope.
+	Command string `yaml:"command"`
+	// Targets limits the action to the targets of these names or types.
+	// Empty means every target.
+	Targets []string `yaml:"targets"`
+}
+
+// AppliesTo reports whether the action runs for target t.
+func (a Action) AppliesTo(t Target) bool {
+	if len(a.Targets) == 0 {
+		return true
+	}
+	return contains(a.Targets, t.Name) || contains(a.Targets, t.Type)
+}
+
+// WithCommand returns a copy of c whose targets, and their matrix
+// variants, run command instead of their own.
+func (c Config) WithCommand(command string) Config {
+	targets := make([]Target, len(c.Targets))
+	for i, t := range c.Targets {
+		t.Command = command
+		if len(t.Matrix) > 0 {
+			matrix := make([]Variant, len(t.Matrix))
+			for j, v := range t.Matrix {
+				v.Command = ""
+				matrix[j] = v
+			}
+			t.Matrix = matrix
+		}
+		targets[i] = t
+	}
+	c.Targets = targets
+	return c
+}
+
+// applyDefaults fills in the scope and label an action leaves out.
+func (a *Action) applyDefaults() {
+	if a.Scope == "" {
+		a.Scope = "run"
+	}
+	if a.Label == "" {
+		a.Label = a.Command
+	}
+}
+
+// checkActions reports actions without a key or command, keys bound twice,
+// unknown scopes and placeholders, and targets that match no target in
+// targets by name or type.
+func checkActions(root *yaml.Node, actions []Action, targets []Target, add func(*yaml.Node, string, ...any)) {
+	var actionNodes []*yaml.Node
+	if n := valueNode(root, "actions"); n != nil && n.Kind == yaml.SequenceNode {
+		actionNodes = n.Content
+	}
+	known := make(map[string]bool)
+	for _, t := range targets {
+		known[t.Name], known[t.Type] = true, true
+	}
+	firstLine := make(map[string]int)
+	for i, a := range actions {
+		node := root
+		if i < len(actionNodes) {
+			node = actionNodes[i]
+		}
+		switch line, dup := firstLine[a.Key]; {
+		case a.Key == "":
+			add(node, "action has no key")
+		case dup:
+			add(nodeOr(node, "key"), "key %q is already bound to the action on line %d", a.Key, line)
+		default:
+			firstLine[a.Key] = nodeOr(node, "key").Line
+		}
+		if a.Scope != "" && !contains(ActionScopes, a.Scope) {
+			add(nodeOr(node, "scope"), "unknown scope %q (supported: %s)", a.Scope, strings.Join(ActionScopes, ", "))
+		}
+
+		if strings.TrimSpace(a.Command) == "" {
+			add(node, "action has no command")
+		}
+		for _, m := range placeholderRe.FindAllStringSubmatch(a.Command, -1) {
+			switch {
+			case m[2] == "test" && a.Scope != "test":
+				add(nodeOr(node, "command"), "command: {test} is only available to actions with scope test")
+			case m[2] != "test" && !placeholders[m[2]]:
+				add(nodeOr(node, "command"), "command: unknown placeholder {%s} (supported: {files}, {file}, {dirs}, {names}, {reporter}, {test})", m[2])
+			}
+		}
+
+		list := valueNode(node, "targets")
+		for j, name := range a.Targets {
+			if known[name] {
+				continue
+			}
+			n := nodeOr(node, "targets")
+			if list != nil && list.Kind == yaml.SequenceNode && j < len(list.Content) {
+				n = list.Content[j]
+			}
+			add(n, "targets: no target is named %q or has that type", name)
+		}
+	}
+}
+
+// actionProblems checks the actions of the root config data against the
+// targets of cfg, which include those of package configs.
+func actionProblems(data []byte, cfg Config) []Problem {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+		return nil
+	}
+	var problems []Problem
+	checkActions(doc.Content[0], cfg.Actions, cfg.Targets, func(n *yaml.Node, format string, args ...any) {
+		problems = append(problems, Problem{Line: n.Line, Column: n.Column, Message: fmt.Sprintf(format, args...)})
+	})
+	return problems
+}
+
+// CheckActionKeys warns about the actions of the config at configPath whose
+// key runs a built-in results binding instead. builtin maps each key of
+// those bindings to the binding's name, e.g. "r" to "rerun".
+func CheckActionKeys(configPath string, builtin map[string]string) ([]Problem, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+		return nil, nil
+	}
+	list := valueNode(doc.Content[0], "actions")
+	if list == nil || list.Kind != yaml.SequenceNode {
+		return nil, nil
+	}
+	var problems []Problem
+	for _, item := range list.Content {
+		n := valueNode(item, "key")
+		if n == nil || n.Kind != yaml.ScalarNode {
+			continue
+		}
+		if name, ok := builtin[n.Value]; ok {
+			problems = append(problems, Problem{
+				Line: n.Line, Column: n.Column, Warning: true,
+				Message: fmt.Sprintf("key %q is bound to %s in the results view, so the action never runs", n.Value, name),
+			})
+		}
+	}
+	return problems, nil
+}
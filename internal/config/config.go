@@ -2,37 +2,70 @@ package config
 
 import (
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
-	"gopkg.in/yaml.v3"
+	"github.com/meijin/lazytest/internal/domain"
 )
 
 const ConfigFileName = ".lazytest.yml"
 
+// Types lists the supported target types, i.e. the test frameworks
+// LazyTest knows how to configure and run.
+var Types = []string{"phpunit", "pest", "vitest", "jest", "pytest", "go", "cargo", "rspec", "bun", "deno"}
+
 // Target represents a single test framework target in a monorepo.
+// Type selects the framework; Name is a free-form label.
 type Target struct {
 	Name            string   `yaml:"name"`
+	Type            string   `yaml:"type"`
 	Command         string   `yaml:"command"`
 	TestDirs        []string `yaml:"test_dirs"`
 	FilePattern     string   `yaml:"file_pattern"`
+	Exclude         []string `yaml:"exclude"`
+	Files           []string `yaml:"files"`
+	Suites          []string `yaml:"suites"`
 	PathStripPrefix string   `yaml:"path_strip_prefix"`
 	WorkingDir      string   `yaml:"working_dir"`
+
+	// PathMap translates file paths between the host and the runner, for
+	// commands that run in a container.
+	PathMap []PathRule `yaml:"path_map"`
+
+	// Env is added to the environment of the target's commands.
+	Env map[string]string `yaml:"env"`
+	// Matrix runs the target once per variant, concurrently.
+	Matrix []Variant `yaml:"matrix"`
 }
 
 // Config represents the lazytest configuration.
 type Config struct {
+	// Include lists globs of package directories whose .lazytest.yml files
+	// are merged in (e.g. "packages/*"). Without it, package configs up to
+	// three levels below the root config are found automatically.
+	Include []string `yaml:"include"`
+	Options `yaml:",inline"`
 	Targets []Target `yaml:"targets"`
+
+	// Sets are the project's shared test sets. Sets saved from the UI are
+	// kept in the state dir instead.
+	Sets []domain.TestSet `yaml:"sets"`
+
+	// Actions are custom commands bound to keys in the results view.
+	Actions []Action `yaml:"actions"`
 }
 
 // Load reads configuration from the given path or auto-detects.
 // If configPath is empty, it looks for .lazytest.yml in the current directory.
 // If .lazytest.yml is not found, it falls back to framework auto-detection.
+// Package configs below it are merged in (see Config.Include).
 func Load(configPath string) (Config, error) {
 	if configPath == "" {
 		configPath = ConfigFileName
 	}
 
-	data, err := os.ReadFile(configPath)
+	cfg, problems, err := loadConfig(configPath, false)
 	if err != nil {
 		if os.IsNotExist(err) {
 			// Fall back to framework auto-detection
@@ -46,14 +79,8 @@ func Load(configPath string) (Config, error) {
 		}
 		return Config{}, err
 	}
-
-	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return Config{}, err
-	}
-
-	for i := range cfg.Targets {
-		cfg.Targets[i].applyDefaults()
+	if errs := errorsOnly(problems); len(errs) > 0 {
+		return Config{}, &Error{Path: configPath, Problems: errs}
 	}
 	cfg.applyDefaults()
 	return cfg, nil
@@ -61,11 +88,56 @@ func Load(configPath string) (Config, error) {
 
 // applyDefaults fills in missing Config-level fields.
 func (c *Config) applyDefaults() {
+	for i := range c.Actions {
+		c.Actions[i].applyDefaults()
+	}
+}
+
+// Framework returns the target's type. Targets without an explicit type
+// (configs written before the type field existed) get one inferred from
+// their name or command.
+func (t Target) Framework() string {
+	if t.Type != "" {
+		return t.Type
+	}
+	return inferType(t)
+}
+
+// inferType guesses the type of a target without one: from the name, where
+// detected targets used to carry their framework ("vitest" or "vitest:web"),
+// then from the first command word naming a framework binary. Anything
+// else is treated as PHPUnit, the original default.
+func inferType(t Target) string {
+	if fw, _, _ := strings.Cut(t.Name, ":"); isType(fw) {
+		return fw
+	}
+	for _, word := range strings.Fields(t.Command) {
+		if base := path.Base(word); isType(base) {
+			return base
+		}
+	}
+	return "phpunit"
 }
 
-// applyDefaults fills in missing Target fields based on the target name.
+func isType(s string) bool {
+	for _, t := range Types {
+		if s == t {
+			return true
+		}
+	}
+	return false
+}
+
+// applyDefaults resolves the target type and fills in missing Target fields
+// based on it. Default test dirs are relative to the working dir.
 func (t *Target) applyDefaults() {
-	switch t.Name {
+	t.Type = t.Framework()
+	if t.Name == "" {
+		t.Name = t.Type
+	}
+	defaultDirs := len(t.TestDirs) == 0
+
+	switch t.Type {
 	case "vitest":
 		if t.FilePattern == "" {
 			t.FilePattern = "*.test.ts,*.test.tsx"
@@ -86,7 +158,79 @@ func (t *Target) applyDefaults() {
 		if len(t.TestDirs) == 0 {
 			t.TestDirs = []string{"src/"}
 		}
-	default: // phpunit and others
+	case "pest":
+		if t.FilePattern == "" {
+			t.FilePattern = "*Test.php"
+		}
+		if t.Command == "" {
+			t.Command = "./vendor/bin/pest --teamcity {files}"
+		}
+		if len(t.TestDirs) == 0 {
+			t.TestDirs = []string{"tests/"}
+		}
+	case "pytest":
+		if t.FilePattern == "" {
+			t.FilePattern = "test_*.py,*_test.py"
+		}
+		if t.Command == "" {
+			t.Command = "PYTHONPATH={reporter}${PYTHONPATH:+:$PYTHONPATH} python -m pytest -p lazytest_pytest {files}"
+		}
+		if len(t.TestDirs) == 0 {
+			t.TestDirs = []string{"tests/"}
+		}
+	case "go":
+		if t.FilePattern == "" {
+			t.FilePattern = "*_test.go"
+		}
+		if t.Command == "" {
+			t.Command = "go test -json {dirs}"
+		}
+		if len(t.TestDirs) == 0 {
+			t.TestDirs = []string{"./"}
+		}
+	case "cargo":
+		if t.FilePattern == "" {
+			// Only files directly in tests/ are integration tests; modules
+			// below it, like tests/common/mod.rs, are shared helpers.
+			t.FilePattern = "./*.rs"
+		}
+		if t.Command == "" {
+			t.Command = "cargo test --no-fail-fast $(printf -- '--test %s ' {names}) 2>&1"
+		}
+		if len(t.TestDirs) == 0 {
+			t.TestDirs = []string{"tests/"}
+		}
+	case "rspec":
+		if t.FilePattern == "" {
+			t.FilePattern = "*_spec.rb"
+		}
+		if t.Command == "" {
+			t.Command = "bundle exec rspec --require {reporter} --format LazytestFormatter {files}"
+		}
+		if len(t.TestDirs) == 0 {
+			t.TestDirs = []string{"spec/"}
+		}
+	case "bun":
+		if t.FilePattern == "" {
+			t.FilePattern = "*.test.ts,*.test.tsx,*.test.js,*.test.jsx,*.spec.ts,*.spec.tsx,*.spec.js,*.spec.jsx"
+		}
+		if t.Command == "" {
+			t.Command = "bun test {files} 2>&1"
+		}
+		if len(t.TestDirs) == 0 {
+			t.TestDirs = []string{"./"}
+		}
+	case "deno":
+		if t.FilePattern == "" {
+			t.FilePattern = "*_test.ts,*.test.ts,*_test.tsx,*.test.tsx,*_test.js,*.test.js"
+		}
+		if t.Command == "" {
+			t.Command = "deno test --reporter=tap {files}"
+		}
+		if len(t.TestDirs) == 0 {
+			t.TestDirs = []string{"./"}
+		}
+	default: // phpunit
 		if t.FilePattern == "" {
 			t.FilePattern = "*Test.php"
 		}
@@ -97,46 +241,89 @@ func (t *Target) applyDefaults() {
 			t.TestDirs = []string{"tests/"}
 		}
 	}
+
+	if defaultDirs && t.WorkingDir != "" {
+		for i, d := range t.TestDirs {
+			t.TestDirs[i] = path.Join(t.WorkingDir, d) + "/"
+		}
+	}
 }
 
-// FindProjectRoot walks up from the given directory looking for config files.
+// FindProjectRoot walks up from the given directory looking for the
+// project root: the outermost directory with a .lazytest.yml below the
+// repository root (so package configs lead to the config that merges
+// them), or the nearest one outside a repository, or if there is none,
+// the nearest one with a framework config or marker file. A .lazytest.yml
+// in the home directory only counts when dir is the home directory.
 func FindProjectRoot(dir string) (string, error) {
 	dir, err := filepath.Abs(dir)
 	if err != nil {
 		return "", err
 	}
 
-	for {
-		// Check for .lazytest.yml
-		if _, err := os.Stat(filepath.Join(dir, ConfigFileName)); err == nil {
-			return dir, nil
-		}
-		// Check for phpunit.xml
-		if _, err := os.Stat(filepath.Join(dir, "phpunit.xml")); err == nil {
-			return dir, nil
-		}
-		if _, err := os.Stat(filepath.Join(dir, "phpunit.xml.dist")); err == nil {
-			return dir, nil
-		}
-		// Check for vitest config files
-		for _, name := range []string{"vitest.config.ts", "vitest.config.mts", "vitest.config.js"} {
-			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
-				return dir, nil
-			}
+	home, _ := os.UserHomeDir()
+	nearest, outermost, inRepo := "", "", false
+	walkUp(dir, func(d string) bool {
+		if d == home && d != dir {
+			return true
 		}
-		// Check for jest config files
-		for _, name := range []string{"jest.config.ts", "jest.config.js", "jest.config.mjs", "jest.config.cjs"} {
-			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
-				return dir, nil
+		if exists(filepath.Join(d, ConfigFileName)) {
+			if nearest == "" {
+				nearest = d
 			}
+			outermost = d
 		}
+		inRepo = exists(filepath.Join(d, ".git"))
+		return inRepo
+	})
+	if inRepo && outermost != "" {
+		return outermost, nil
+	}
+	if nearest != "" {
+		return nearest, nil
+	}
 
+	if root, ok := walkUp(dir, hasFrameworkFiles); ok {
+		return root, nil
+	}
+	return "", os.ErrNotExist
+}
+
+// walkUp returns the first of dir and its parents for which match is true.
+func walkUp(dir string, match func(string) bool) (string, bool) {
+	for {
+		if match(dir) {
+			return dir, true
+		}
 		parent := filepath.Dir(dir)
 		if parent == dir {
-			break
+			return "", false
 		}
 		dir = parent
 	}
+}
 
-	return "", os.ErrNotExist
+// hasFrameworkFiles reports whether dir holds a config or marker file of a
+// supported framework.
+func hasFrameworkFiles(dir string) bool {
+	for _, name := range []string{
+		"phpunit.xml", "phpunit.xml.dist",
+		"vitest.config.ts", "vitest.config.mts", "vitest.config.js",
+		"jest.config.ts", "jest.config.js", "jest.config.mjs", "jest.config.cjs",
+	} {
+		if exists(filepath.Join(dir, name)) {
+			return true
+		}
+	}
+	for _, m := range markerFrameworks {
+		if _, ok := m.find(dir); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
 }
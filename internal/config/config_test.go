@@ -1,8 +1,12 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -283,3 +287,1229 @@ func TestFindProjectRootVitest(t *testing.T) {
 		t.Errorf("root = %q, want %q", root, dir)
 	}
 }
+
+func TestFindProjectRootPrefersConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "frontend", "src")
+	os.MkdirAll(sub, 0755)
+	os.WriteFile(filepath.Join(dir, ConfigFileName), []byte("targets: []\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "frontend", "vitest.config.ts"), []byte(""), 0644)
+
+	root, err := FindProjectRoot(sub)
+	if err != nil {
+		t.Fatalf("FindProjectRoot error: %v", err)
+	}
+	if root != dir {
+		t.Errorf("root = %q, want %q", root, dir)
+	}
+}
+
+func TestDetectFrameworksMultiplePackages(t *testing.T) {
+	dir := t.TempDir()
+	for _, pkg := range []string{"web", "admin"} {
+		pkgDir := filepath.Join(dir, "packages", pkg)
+		os.MkdirAll(pkgDir, 0755)
+		os.WriteFile(filepath.Join(pkgDir, "vitest.config.ts"), []byte(""), 0644)
+	}
+
+	targets, err := DetectFrameworks(dir)
+	if err != nil {
+		t.Fatalf("DetectFrameworks error: %v", err)
+	}
+	if len(targets) != 2 {
+		t.Fatalf("got %d targets, want 2: %+v", len(targets), targets)
+	}
+
+	byName := make(map[string]Target)
+	for _, tg := range targets {
+		byName[tg.Name] = tg
+	}
+	web, ok := byName["vitest:web"]
+	if !ok {
+		t.Fatalf("missing vitest:web target: %+v", targets)
+	}
+	if web.WorkingDir != "packages/web/" {
+		t.Errorf("vitest:web WorkingDir = %q", web.WorkingDir)
+	}
+	if web.Command != "npx vitest run --reporter={reporter} {files}" {
+		t.Errorf("vitest:web Command = %q, want vitest default", web.Command)
+	}
+	if admin, ok := byName["vitest:admin"]; !ok || admin.WorkingDir != "packages/admin/" {
+		t.Errorf("vitest:admin = %+v", admin)
+	}
+}
+
+func TestDetectFrameworksCollidingPackageNames(t *testing.T) {
+	dir := t.TempDir()
+	for _, rel := range []string{filepath.Join("apps", "web"), filepath.Join("packages", "web")} {
+		os.MkdirAll(filepath.Join(dir, rel), 0755)
+		os.WriteFile(filepath.Join(dir, rel, "jest.config.js"), []byte(""), 0644)
+	}
+
+	targets, err := DetectFrameworks(dir)
+	if err != nil {
+		t.Fatalf("DetectFrameworks error: %v", err)
+	}
+	names := make(map[string]bool)
+	for _, tg := range targets {
+		names[tg.Name] = true
+	}
+	if !names["jest:apps/web"] || !names["jest:packages/web"] {
+		t.Errorf("names = %v, want jest:apps/web and jest:packages/web", names)
+	}
+}
+
+func TestTargetFramework(t *testing.T) {
+	if got := (Target{Name: "vitest:web"}).Framework(); got != "vitest" {
+		t.Errorf("Framework() = %q, want vitest", got)
+	}
+	if got := (Target{Name: "phpunit"}).Framework(); got != "phpunit" {
+		t.Errorf("Framework() = %q, want phpunit", got)
+	}
+	if got := (Target{Name: "frontend", Type: "jest"}).Framework(); got != "jest" {
+		t.Errorf("Framework() = %q, want jest", got)
+	}
+	if got := (Target{Name: "web", Command: "pnpm exec vitest run {files}"}).Framework(); got != "vitest" {
+		t.Errorf("Framework() = %q, want vitest from the command", got)
+	}
+	if got := (Target{Name: "api", Command: "./vendor/bin/pest {files}"}).Framework(); got != "pest" {
+		t.Errorf("Framework() = %q, want pest from the command", got)
+	}
+	if got := (Target{Name: "legacy"}).Framework(); got != "phpunit" {
+		t.Errorf("Framework() = %q, want phpunit", got)
+	}
+}
+
+func TestLoadTargetType(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, ConfigFileName)
+	writeFile(t, configPath, `targets:
+  - name: jest-web
+    type: jest
+  - name: vitest
+`)
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	web := cfg.Targets[0]
+	if web.Name != "jest-web" || web.Type != "jest" {
+		t.Errorf("target = %q (%q), want jest-web (jest)", web.Name, web.Type)
+	}
+	if web.Command != "npx jest --reporters={reporter} -- {files}" {
+		t.Errorf("Command default = %q, want the jest command", web.Command)
+	}
+	if cfg.Targets[1].Type != "vitest" {
+		t.Errorf("inferred Type = %q, want vitest", cfg.Targets[1].Type)
+	}
+}
+
+func TestLoadUnknownTargetType(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, ConfigFileName)
+	writeFile(t, configPath, `targets:
+  - name: web
+    type: mocha
+`)
+
+	_, err := Load(configPath)
+	if err == nil || !strings.Contains(err.Error(), `unknown type "mocha"`) {
+		t.Errorf("Load error = %v, want an unknown type error", err)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("writing %s: %v", path, err)
+	}
+}
+
+func TestDetectFrameworksNpmWorkspacesDeepPackages(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "package.json"), `{"workspaces": ["apps/**/packages/*"], "devDependencies": {"vitest": "^3"}}`)
+	// Package 5 levels deep, detected from devDependencies (no config file)
+	pkgDir := filepath.Join(dir, "apps", "shop", "frontend", "packages", "cart")
+	writeFile(t, filepath.Join(pkgDir, "package.json"), `{"devDependencies": {"jest": "^29"}}`)
+	os.MkdirAll(filepath.Join(pkgDir, "src"), 0755)
+
+	targets, err := DetectFrameworks(dir)
+	if err != nil {
+		t.Fatalf("DetectFrameworks error: %v", err)
+	}
+	if len(targets) != 1 {
+		t.Fatalf("got %d targets, want 1: %+v", len(targets), targets)
+	}
+	jt := targets[0]
+	if jt.Name != "jest" {
+		t.Errorf("Name = %q, want jest", jt.Name)
+	}
+	if jt.WorkingDir != "apps/shop/frontend/packages/cart/" {
+		t.Errorf("WorkingDir = %q", jt.WorkingDir)
+	}
+	if len(jt.TestDirs) != 1 || jt.TestDirs[0] != "apps/shop/frontend/packages/cart/src/" {
+		t.Errorf("TestDirs = %v", jt.TestDirs)
+	}
+}
+
+func TestDetectFrameworksPnpmWorkspace(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "pnpm-workspace.yaml"), "packages:\n  - 'packages/*'\n  - '!packages/legacy'\n")
+	writeFile(t, filepath.Join(dir, "packages", "web", "package.json"), `{"devDependencies": {"vitest": "^3"}}`)
+	writeFile(t, filepath.Join(dir, "packages", "legacy", "package.json"), `{"devDependencies": {"vitest": "^1"}}`)
+
+	targets, err := DetectFrameworks(dir)
+	if err != nil {
+		t.Fatalf("DetectFrameworks error: %v", err)
+	}
+	if len(targets) != 1 {
+		t.Fatalf("got %d targets, want 1: %+v", len(targets), targets)
+	}
+	if targets[0].WorkingDir != "packages/web/" {
+		t.Errorf("WorkingDir = %q", targets[0].WorkingDir)
+	}
+	if targets[0].Command != "pnpm exec vitest run --reporter={reporter} {files}" {
+		t.Errorf("Command = %q", targets[0].Command)
+	}
+}
+
+func TestDetectFrameworksWithoutWorkspacePackages(t *testing.T) {
+	for name, files := range map[string]map[string]string{
+		"composer path repository outside the project": {
+			"composer.json": `{"repositories": [{"type": "path", "url": "../shared-lib"}], "require-dev": {"phpunit/phpunit": "^11"}}`,
+		},
+		"pnpm workspace without packages": {
+			"pnpm-workspace.yaml": "onlyBuiltDependencies: [esbuild]\n",
+			"phpunit.xml":         "<phpunit/>",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			for path, content := range files {
+				writeFile(t, filepath.Join(dir, path), content)
+			}
+			writeFile(t, filepath.Join(dir, "web", "vitest.config.ts"), "")
+
+			targets, err := DetectFrameworks(dir)
+			if err != nil {
+				t.Fatalf("DetectFrameworks error: %v", err)
+			}
+			var dirs []string
+			for _, tg := range targets {
+				dirs = append(dirs, tg.WorkingDir)
+			}
+			if want := []string{"", "web/"}; !reflect.DeepEqual(dirs, want) {
+				t.Errorf("working dirs = %q, want %q", dirs, want)
+			}
+		})
+	}
+}
+
+func TestDetectFrameworksOutsideWorkspacePackages(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "package.json"), `{"workspaces": ["frontend/*"]}`)
+	writeFile(t, filepath.Join(dir, "frontend", "app", "package.json"), `{"devDependencies": {"vitest": "^3"}}`)
+	writeFile(t, filepath.Join(dir, "backend", "phpunit.xml"), "<phpunit/>")
+
+	targets, err := DetectFrameworks(dir)
+	if err != nil {
+		t.Fatalf("DetectFrameworks error: %v", err)
+	}
+	var dirs []string
+	for _, tg := range targets {
+		dirs = append(dirs, tg.WorkingDir)
+	}
+	if want := []string{"frontend/app/", "backend/"}; !reflect.DeepEqual(dirs, want) {
+		t.Errorf("working dirs = %q, want %q", dirs, want)
+	}
+}
+
+func TestDetectFrameworksComposerPathRepositories(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "composer.json"), `{"repositories": [{"type": "path", "url": "modules/*"}]}`)
+	writeFile(t, filepath.Join(dir, "modules", "billing", "composer.json"), `{"require-dev": {"pestphp/pest": "^2"}}`)
+	writeFile(t, filepath.Join(dir, "modules", "auth", "composer.json"), `{"require-dev": {"phpunit/phpunit": "^11"}}`)
+
+	targets, err := DetectFrameworks(dir)
+	if err != nil {
+		t.Fatalf("DetectFrameworks error: %v", err)
+	}
+	byDir := make(map[string]Target)
+	for _, tg := range targets {
+		byDir[tg.WorkingDir] = tg
+	}
+	if tg := byDir["modules/billing/"]; tg.Name != "pest" || tg.Type != "pest" || tg.Command != "./vendor/bin/pest --teamcity {files}" {
+		t.Errorf("billing target = %+v, want pest", tg)
+	}
+	if tg := byDir["modules/auth/"]; tg.Name != "phpunit" || len(tg.TestDirs) != 1 || tg.TestDirs[0] != "modules/auth/tests/" {
+		t.Errorf("auth target = %+v, want phpunit with modules/auth/tests/", tg)
+	}
+}
+
+func TestDetectFrameworksPestWithPHPUnitXML(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "phpunit.xml"), `<phpunit><testsuites><testsuite name="Unit"><directory>tests/Unit</directory></testsuite></testsuites></phpunit>`)
+	writeFile(t, filepath.Join(dir, "composer.json"), `{"require-dev": {"pestphp/pest": "^2"}}`)
+
+	targets, err := DetectFrameworks(dir)
+	if err != nil {
+		t.Fatalf("DetectFrameworks error: %v", err)
+	}
+	if len(targets) != 1 {
+		t.Fatalf("targets = %+v, want one pest target", targets)
+	}
+	if tg := targets[0]; tg.Name != "pest" || tg.Type != "pest" || tg.Command != "./vendor/bin/pest --teamcity {files}" {
+		t.Errorf("target = %+v, want pest", tg)
+	}
+}
+
+func TestDetectFrameworksNxProjects(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "nx.json"), `{"projects": {"api": {"root": "services/api"}, "ui": "libs/ui"}}`)
+	writeFile(t, filepath.Join(dir, "services", "api", "jest.config.ts"), "")
+	writeFile(t, filepath.Join(dir, "libs", "ui", "vitest.config.ts"), "")
+
+	targets, err := DetectFrameworks(dir)
+	if err != nil {
+		t.Fatalf("DetectFrameworks error: %v", err)
+	}
+	if len(targets) != 2 {
+		t.Fatalf("got %d targets, want 2: %+v", len(targets), targets)
+	}
+}
+
+func TestQueryFrameworks(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "web", "vitest.config.ts"), "")
+	writeFile(t, filepath.Join(dir, "node_modules", ".bin", "vitest"), "")
+
+	calls := 0
+	orig := runFrameworkQuery
+	defer func() { runFrameworkQuery = orig }()
+	runFrameworkQuery = func(pkgDir, bin string, args []string) ([]byte, error) {
+		calls++
+		abs, _ := filepath.Abs(pkgDir)
+		return []byte(`> some log line
+[{"file": "` + filepath.ToSlash(filepath.Join(abs, "lib", "__tests__", "a.ts")) + `"},
+ {"file": "` + filepath.ToSlash(filepath.Join(abs, "test", "b.spec.ts")) + `"}]`), nil
+	}
+
+	targets, err := DetectFrameworks(dir)
+	if err != nil {
+		t.Fatalf("DetectFrameworks error: %v", err)
+	}
+	if len(targets) != 1 {
+		t.Fatalf("got %d targets, want 1: %+v", len(targets), targets)
+	}
+	if calls != 0 {
+		t.Fatalf("DetectFrameworks queried the framework %d times, want 0", calls)
+	}
+	QueryFrameworks(dir, targets)
+	vt := targets[0]
+	if len(vt.TestDirs) != 2 || vt.TestDirs[0] != "web/lib/" || vt.TestDirs[1] != "web/test/" {
+		t.Errorf("TestDirs = %v, want [web/lib/ web/test/]", vt.TestDirs)
+	}
+	if vt.FilePattern != "**/__tests__/**/*.ts,*.spec.ts" {
+		t.Errorf("FilePattern = %q", vt.FilePattern)
+	}
+
+	// Later detections are served from the cache.
+	targets, err = DetectFrameworks(dir)
+	if err != nil {
+		t.Fatalf("DetectFrameworks error: %v", err)
+	}
+	if !reflect.DeepEqual(targets[0], vt) {
+		t.Errorf("cached target = %+v, want %+v", targets[0], vt)
+	}
+	QueryFrameworks(dir, targets)
+	if calls != 1 {
+		t.Errorf("framework queried %d times, want 1", calls)
+	}
+}
+
+func TestQueryFrameworksSkipsQueryWithoutLocalBinary(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "jest.config.js"), "")
+
+	orig := runFrameworkQuery
+	defer func() { runFrameworkQuery = orig }()
+	runFrameworkQuery = func(string, string, []string) ([]byte, error) {
+		t.Fatal("framework should not be queried without node_modules/.bin/jest")
+		return nil, nil
+	}
+
+	targets, err := DetectFrameworks(dir)
+	if err != nil {
+		t.Fatalf("DetectFrameworks error: %v", err)
+	}
+	QueryFrameworks(dir, targets)
+	if len(targets) != 1 || targets[0].FilePattern != "*.test.ts,*.test.tsx,*.test.js,*.test.jsx" {
+		t.Errorf("targets = %+v, want jest defaults", targets)
+	}
+}
+
+func TestDetectFrameworksPHPUnitSuiteSettings(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "api", "phpunit.xml"), `<?xml version="1.0"?>
+<phpunit>
+  <testsuites>
+    <testsuite name="Unit">
+      <directory suffix="Spec.php">tests/Unit</directory>
+      <exclude>tests/Unit/Fixtures</exclude>
+    </testsuite>
+    <testsuite name="Integration">
+      <directory suffix="Spec.php">tests/Integration</directory>
+      <file>tests/Legacy/OldCheck.php</file>
+    </testsuite>
+  </testsuites>
+</phpunit>`)
+
+	targets, err := DetectFrameworks(dir)
+	if err != nil {
+		t.Fatalf("DetectFrameworks error: %v", err)
+	}
+	if len(targets) != 1 {
+		t.Fatalf("got %d targets, want 1: %+v", len(targets), targets)
+	}
+	tg := targets[0]
+	if tg.FilePattern != "*Spec.php" {
+		t.Errorf("FilePattern = %q, want *Spec.php", tg.FilePattern)
+	}
+	if len(tg.Exclude) != 1 || tg.Exclude[0] != "/api/tests/Unit/Fixtures" {
+		t.Errorf("Exclude = %v, want [/api/tests/Unit/Fixtures]", tg.Exclude)
+	}
+	if len(tg.Files) != 1 || tg.Files[0] != "api/tests/Legacy/OldCheck.php" {
+		t.Errorf("Files = %v, want [api/tests/Legacy/OldCheck.php]", tg.Files)
+	}
+	if len(tg.Suites) != 2 || tg.Suites[0] != "Unit" || tg.Suites[1] != "Integration" {
+		t.Errorf("Suites = %v, want [Unit Integration]", tg.Suites)
+	}
+}
+
+func TestParsePHPUnitConfigMixedSuffixes(t *testing.T) {
+	pc := parsePHPUnitConfig([]byte(`<phpunit><testsuites>
+  <testsuite name="Unit"><directory>tests/Unit</directory></testsuite>
+  <testsuite name="Feature"><directory prefix="Test" suffix=".php">tests/Feature</directory></testsuite>
+</testsuites></phpunit>`))
+
+	want := "tests/Unit/**/*Test.php,tests/Feature/**/Test*.php"
+	if pc.FilePattern != want {
+		t.Errorf("FilePattern = %q, want %q", pc.FilePattern, want)
+	}
+}
+
+func TestDetectFrameworksMarkerFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "api", "go.mod"), "module api\n")
+	writeFile(t, filepath.Join(dir, "ml", "pyproject.toml"), "[tool.pytest.ini_options]\ntestpaths = [\"tests\"]\n")
+	writeFile(t, filepath.Join(dir, "docs", "pyproject.toml"), "[project]\nname = \"docs\"\n")
+	writeFile(t, filepath.Join(dir, "core", "Cargo.toml"), "[package]\nname = \"core\"\n")
+	writeFile(t, filepath.Join(dir, "web", ".rspec"), "--require spec_helper\n")
+	writeFile(t, filepath.Join(dir, "edge", "bunfig.toml"), "")
+	writeFile(t, filepath.Join(dir, "fn", "deno.json"), "{}")
+
+	targets, err := DetectFrameworks(dir)
+	if err != nil {
+		t.Fatalf("DetectFrameworks error: %v", err)
+	}
+
+	want := map[string]struct{ workingDir, testDir, command string }{
+		"go":     {"api/", "api/", "go test -json {dirs}"},
+		"pytest": {"ml/", "ml/tests/", "PYTHONPATH={reporter}${PYTHONPATH:+:$PYTHONPATH} python -m pytest -p lazytest_pytest {files}"},
+		"cargo":  {"core/", "core/tests/", "cargo test --no-fail-fast $(printf -- '--test %s ' {names}) 2>&1"},
+		"rspec":  {"web/", "web/spec/", "bundle exec rspec --require {reporter} --format LazytestFormatter {files}"},
+		"bun":    {"edge/", "edge/", "bun test {files} 2>&1"},
+		"deno":   {"fn/", "fn/", "deno test --reporter=tap {files}"},
+	}
+	if len(targets) != len(want) {
+		t.Fatalf("got %d targets, want %d: %+v", len(targets), len(want), targets)
+	}
+	for _, tg := range targets {
+		w, ok := want[tg.Name]
+		if !ok {
+			t.Errorf("unexpected target %q", tg.Name)
+			continue
+		}
+		if tg.WorkingDir != w.workingDir || len(tg.TestDirs) != 1 || tg.TestDirs[0] != w.testDir || tg.Command != w.command {
+			t.Errorf("%s = %+v, want working_dir %q, test_dirs [%s], command %q", tg.Name, tg, w.workingDir, w.testDir, w.command)
+		}
+	}
+}
+
+func TestLoadRejectsUnknownKeys(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, ConfigFileName)
+	writeFile(t, configPath, `runner: docker
+targets:
+  - name: phpunit
+    comand: "phpunit {files}"
+`)
+
+	_, err := Load(configPath)
+	var cfgErr *Error
+	if !errors.As(err, &cfgErr) {
+		t.Fatalf("Load error = %v, want *Error", err)
+	}
+	want := []Problem{
+		{Line: 1, Column: 1, Message: `unknown key "runner"`},
+		{Line: 4, Column: 5, Message: `unknown key "comand" (did you mean "command"?)`},
+	}
+	if !reflect.DeepEqual(cfgErr.Problems, want) {
+		t.Errorf("Problems = %+v, want %+v", cfgErr.Problems, want)
+	}
+	if !strings.HasPrefix(err.Error(), configPath+":1:1: unknown key") {
+		t.Errorf("Error() = %q, want path:line:column prefix", err.Error())
+	}
+}
+
+func TestLoadReportsSemanticProblems(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, ConfigFileName)
+	writeFile(t, configPath, `targets:
+  - name: web
+    type: vitest
+    command: "npx vitest run {files} --shard={shard}"
+    test_dirs: [web/src/, shared/]
+    working_dir: web/
+  - name: web
+    type: jest
+    command: "PATH=${PATH} npx jest"
+`)
+
+	_, err := Load(configPath)
+	var cfgErr *Error
+	if !errors.As(err, &cfgErr) {
+		t.Fatalf("Load error = %v, want *Error", err)
+	}
+	var got []string
+	for _, p := range cfgErr.Problems {
+		got = append(got, fmt.Sprintf("%d:%d %s", p.Line, p.Column, p.Message))
+	}
+	want := []string{
+		`4:14 command: unknown placeholder {shard} (supported: {files}, {file}, {dirs}, {names}, {reporter})`,
+		`7:11 duplicate target name "web" (first defined on line 2)`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("problems =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestCheckReportsWarnings(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	os.MkdirAll("web/src", 0755)
+	os.MkdirAll("shared", 0755)
+	os.MkdirAll("src", 0755)
+	writeFile(t, ConfigFileName, `targets:
+  - name: web
+    type: vitest
+    test_dirs: [web/src/, shared/]
+    working_dir: web/
+  - name: lint
+    type: jest
+    command: "npx jest --ci"
+`)
+
+	if _, err := Load(ConfigFileName); err != nil {
+		t.Fatalf("Load error = %v, want warnings to be tolerated", err)
+	}
+	problems, err := Check(ConfigFileName)
+	if err != nil {
+		t.Fatalf("Check error: %v", err)
+	}
+	want := []Problem{
+		{Line: 4, Column: 27, Message: `test_dirs: "shared/" is not under working_dir "web/", so its paths can't be made relative to it`, Warning: true},
+		{Line: 8, Column: 14, Message: `command: no {files}, {file}, {dirs} or {names} placeholder, so the selected tests would be ignored`, Warning: true},
+	}
+	if !reflect.DeepEqual(problems, want) {
+		t.Errorf("Check = %+v, want %+v", problems, want)
+	}
+	if got := (&Error{Path: ConfigFileName, Problems: want[1:]}).Error(); !strings.HasPrefix(got, ConfigFileName+":8:14: warning: command:") {
+		t.Errorf("Error() = %q, want a warning prefix", got)
+	}
+}
+
+func TestCheckReportsMissingDirs(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	os.MkdirAll("web/src", 0755)
+	writeFile(t, ConfigFileName, `targets:
+  - name: web
+    type: vitest
+    test_dirs: [web/src/, web/app/]
+    working_dir: web/
+  - name: api
+    type: phpunit
+`)
+
+	if _, err := Load(ConfigFileName); err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	problems, err := Check(ConfigFileName)
+	if err != nil {
+		t.Fatalf("Check returned error: %v", err)
+	}
+	want := []Problem{
+		{Line: 4, Column: 27, Message: `test_dirs: "web/app/" does not exist`},
+		{Line: 6, Column: 5, Message: `default test dir "tests/" does not exist; set test_dirs`},
+	}
+	if !reflect.DeepEqual(problems, want) {
+		t.Errorf("Check = %+v, want %+v", problems, want)
+	}
+}
+
+func TestGenerateRoundTrips(t *testing.T) {
+	targets := []Target{
+		{Name: "phpunit", Type: "phpunit", Command: "./vendor/bin/phpunit --teamcity {files}", TestDirs: []string{"tests/"}, FilePattern: "*Test.php", Exclude: []string{"/tests/Fixtures"}},
+		{Name: "vitest:web", Type: "vitest", Command: "npx vitest run --reporter={reporter} {files}", TestDirs: []string{"web/src/"}, FilePattern: "*.test.ts", WorkingDir: "web/"},
+	}
+
+	data, err := Generate(targets)
+	if err != nil {
+		t.Fatalf("Generate error: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "# LazyTest configuration") || !strings.Contains(string(data), "# Detected in web/") {
+		t.Errorf("generated config lacks comments:\n%s", data)
+	}
+
+	cfg, problems := parseConfig(data, pkgConfig{}, false)
+	if len(problems) > 0 {
+		t.Fatalf("generated config has problems: %+v\n%s", problems, data)
+	}
+	if !reflect.DeepEqual(cfg.Targets, targets) {
+		t.Errorf("round trip = %+v, want %+v", cfg.Targets, targets)
+	}
+}
+
+func TestUnconfiguredSkipsConfiguredPackages(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, ConfigFileName)
+	writeFile(t, existing, `targets:
+  - name: vitest
+    command: "npx vitest run {files}"
+    working_dir: web/
+  - name: api
+    type: phpunit
+`)
+	detected := []Target{
+		{Name: "vitest", Type: "vitest", WorkingDir: "web/"},
+		{Name: "phpunit", Type: "phpunit"},
+		{Name: "vitest", Type: "vitest", WorkingDir: "admin/"},
+		{Name: "jest", Type: "jest", WorkingDir: "mobile/"},
+	}
+
+	added, taken, err := Unconfigured(existing, detected)
+	if err != nil {
+		t.Fatalf("Unconfigured error: %v", err)
+	}
+	var names []string
+	for _, a := range added {
+		names = append(names, a.Name)
+	}
+	if want := []string{"vitest:admin", "jest"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("added = %v, want %v", names, want)
+	}
+	if want := []string{"vitest", "api"}; !reflect.DeepEqual(taken, want) {
+		t.Errorf("taken = %v, want %v", taken, want)
+	}
+}
+
+func TestAppendTargetsKeepsExistingText(t *testing.T) {
+	existing := `# my project
+targets:
+    - name: api   # hand edit
+      type: phpunit
+
+      command: "phpunit {files}"
+`
+	data, err := AppendTargets([]byte(existing), []Target{
+		{Name: "jest", Type: "jest", Command: "npx jest {files}", WorkingDir: "mobile/"},
+	})
+	if err != nil {
+		t.Fatalf("AppendTargets error: %v", err)
+	}
+	if !strings.HasPrefix(string(data), existing) {
+		t.Errorf("existing text changed:\n%s", data)
+	}
+
+	cfg, problems := parseConfig(data, pkgConfig{}, false)
+	if len(problems) > 0 {
+		t.Fatalf("merged config has problems: %+v\n%s", problems, data)
+	}
+	if len(cfg.Targets) != 2 || cfg.Targets[1].Name != "jest" || cfg.Targets[1].WorkingDir != "mobile/" {
+		t.Errorf("targets = %+v, want api and jest", cfg.Targets)
+	}
+}
+
+func TestAppendTargetsReencodesFlowList(t *testing.T) {
+	existing := `# the API
+targets: [{name: api, type: phpunit}]
+`
+	data, err := AppendTargets([]byte(existing), []Target{{Name: "jest", Type: "jest"}})
+	if err != nil {
+		t.Fatalf("AppendTargets error: %v", err)
+	}
+	cfg, problems := parseConfig(data, pkgConfig{}, false)
+	if len(problems) > 0 {
+		t.Fatalf("merged config has problems: %+v\n%s", problems, data)
+	}
+	if len(cfg.Targets) != 2 || !strings.Contains(string(data), "# the API") {
+		t.Errorf("merged config =\n%s", data)
+	}
+}
+
+func TestLoadMergesPackageConfigs(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	writeFile(t, ConfigFileName, `targets:
+  - name: api
+    type: phpunit
+`)
+	writeFile(t, "packages/web/"+ConfigFileName, `targets:
+  - name: vitest
+    type: vitest
+    test_dirs: [src/]
+    exclude: [/src/fixtures/, snapshots/]
+`)
+	writeFile(t, "packages/admin/"+ConfigFileName, `targets:
+  - name: jest
+    type: jest
+    working_dir: app/
+`)
+
+	cfg, err := Load(ConfigFileName)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if len(cfg.Targets) != 3 {
+		t.Fatalf("expected 3 targets, got %d", len(cfg.Targets))
+	}
+
+	admin, web := cfg.Targets[1], cfg.Targets[2]
+	if admin.Name != "admin/jest" || admin.WorkingDir != "packages/admin/app/" {
+		t.Errorf("admin target = %q in %q, want admin/jest in packages/admin/app/", admin.Name, admin.WorkingDir)
+	}
+	if web.Name != "web/vitest" || web.WorkingDir != "packages/web/" {
+		t.Errorf("web target = %q in %q, want web/vitest in packages/web/", web.Name, web.WorkingDir)
+	}
+	if want := []string{"packages/web/src/"}; !reflect.DeepEqual(web.TestDirs, want) {
+		t.Errorf("web test_dirs = %v, want %v", web.TestDirs, want)
+	}
+	if want := []string{"/packages/web/src/fixtures/", "snapshots/"}; !reflect.DeepEqual(web.Exclude, want) {
+		t.Errorf("web exclude = %v, want %v", web.Exclude, want)
+	}
+}
+
+func TestLoadPackageConfigsFromInclude(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	writeFile(t, ConfigFileName, `include: ["services/*"]
+targets:
+  - name: api
+    type: phpunit
+`)
+	writeFile(t, "services/billing/"+ConfigFileName, "targets:\n  - type: go\n")
+	writeFile(t, "tools/lint/"+ConfigFileName, "targets:\n  - type: jest\n")
+
+	cfg, err := Load(ConfigFileName)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	var names []string
+	for _, tgt := range cfg.Targets {
+		names = append(names, tgt.Name)
+	}
+	if want := []string{"api", "billing/go"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("targets = %v, want %v", names, want)
+	}
+}
+
+func TestLoadReportsPackageConfigProblems(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	writeFile(t, ConfigFileName, `targets:
+  - name: web/vitest
+    type: vitest
+    working_dir: web/
+`)
+	writeFile(t, "web/"+ConfigFileName, `targets:
+  - name: vitest
+    type: vitest
+  - name: e2e
+    type: vitst
+`)
+
+	_, err := Load(ConfigFileName)
+	cfgErr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("Load error = %v, want *Error", err)
+	}
+	pkgFile := filepath.Join("web", ConfigFileName)
+	want := []Problem{
+		{File: pkgFile, Line: 5, Column: 11, Message: `unknown type "vitst" (supported: phpunit, pest, vitest, jest, pytest, go, cargo, rspec, bun, deno)`},
+		{File: pkgFile, Message: `duplicate target name "web/vitest" (also defined in .lazytest.yml)`},
+	}
+	if !reflect.DeepEqual(cfgErr.Problems, want) {
+		t.Errorf("problems = %+v, want %+v", cfgErr.Problems, want)
+	}
+	if !strings.Contains(cfgErr.Error(), pkgFile+":5:11:") {
+		t.Errorf("error should point into the package config, got:\n%s", cfgErr.Error())
+	}
+}
+
+func TestFindProjectRootPrefersOutermostConfig(t *testing.T) {
+	dir := t.TempDir()
+	repo := filepath.Join(dir, "repo")
+	sub := filepath.Join(repo, "packages", "web", "src")
+	os.MkdirAll(filepath.Join(repo, ".git"), 0755)
+	os.MkdirAll(sub, 0755)
+	os.WriteFile(filepath.Join(dir, ConfigFileName), []byte("targets: []\n"), 0644)
+	os.WriteFile(filepath.Join(repo, ConfigFileName), []byte("targets: []\n"), 0644)
+	os.WriteFile(filepath.Join(repo, "packages", "web", ConfigFileName), []byte("targets: []\n"), 0644)
+
+	root, err := FindProjectRoot(sub)
+	if err != nil {
+		t.Fatalf("FindProjectRoot error: %v", err)
+	}
+	if root != repo {
+		t.Errorf("root = %q, want %q", root, repo)
+	}
+}
+
+func TestFindProjectRootSkipsHomeConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	project := filepath.Join(home, "work", "api")
+	sub := filepath.Join(project, "tests")
+	os.MkdirAll(sub, 0755)
+	os.WriteFile(filepath.Join(home, ConfigFileName), []byte("targets: []\n"), 0644)
+	os.WriteFile(filepath.Join(home, "work", ConfigFileName), []byte("targets: []\n"), 0644)
+	os.WriteFile(filepath.Join(project, ConfigFileName), []byte("targets: []\n"), 0644)
+
+	root, err := FindProjectRoot(sub)
+	if err != nil {
+		t.Fatalf("FindProjectRoot error: %v", err)
+	}
+	if root != project {
+		t.Errorf("root = %q, want the nearest config's %q", root, project)
+	}
+
+	// A config in the home directory doesn't claim the projects below it.
+	other := filepath.Join(home, "scratch")
+	os.MkdirAll(other, 0755)
+	os.WriteFile(filepath.Join(other, "go.mod"), []byte("module scratch\n"), 0644)
+	if root, err := FindProjectRoot(other); err != nil || root != other {
+		t.Errorf("FindProjectRoot(%q) = %q, %v, want %q", other, root, err, other)
+	}
+	if root, err := FindProjectRoot(home); err != nil || root != home {
+		t.Errorf("FindProjectRoot(%q) = %q, %v, want %q", home, root, err, home)
+	}
+}
+
+func TestLoadSets(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ConfigFileName)
+	writeFile(t, path, `targets:
+  - name: vitest
+    type: vitest
+sets:
+  - name: checkout-flow
+    query: checkout
+    files:
+      - src/cart.test.ts
+      - src/payment.test.ts
+  - name: api
+    query: "@phpunit api"
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if len(cfg.Sets) != 2 {
+		t.Fatalf("Sets length = %d, want 2", len(cfg.Sets))
+	}
+	if s := cfg.Sets[0]; s.Name != "checkout-flow" || s.Query != "checkout" ||
+		!reflect.DeepEqual(s.Files, []string{"src/cart.test.ts", "src/payment.test.ts"}) {
+		t.Errorf("Sets[0] = %+v", s)
+	}
+	if s := cfg.Sets[1]; s.Name != "api" || s.Query != "@phpunit api" || s.Files != nil {
+		t.Errorf("Sets[1] = %+v", s)
+	}
+}
+
+func TestLoadReportsSetProblems(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ConfigFileName)
+	writeFile(t, path, `targets:
+  - name: vitest
+    type: vitest
+sets:
+  - query: checkout
+  - name: api
+    query: api
+  - name: api
+    files: [tests/ApiTest.php]
+  - name: empty
+`)
+
+	_, err := Load(path)
+	cfgErr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("Load error = %v, want *Error", err)
+	}
+	want := []Problem{
+		{Line: 5, Column: 5, Message: `set has no name`},
+		{Line: 8, Column: 11, Message: `duplicate set name "api" (first defined on line 6)`},
+		{Line: 10, Column: 5, Message: `set "empty" has neither a query nor files`},
+	}
+	if !reflect.DeepEqual(cfgErr.Problems, want) {
+		t.Errorf("problems = %+v, want %+v", cfgErr.Problems, want)
+	}
+}
+
+func TestLoadRejectsPackageSets(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	writeFile(t, ConfigFileName, "targets: []\n")
+	writeFile(t, "web/"+ConfigFileName, `targets:
+  - name: vitest
+    type: vitest
+sets:
+  - name: web
+    query: web
+`)
+
+	_, err := Load(ConfigFileName)
+	cfgErr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("Load error = %v, want *Error", err)
+	}
+	if len(cfgErr.Problems) != 1 || !strings.Contains(cfgErr.Problems[0].Message, "sets") {
+		t.Errorf("problems = %+v, want one about sets", cfgErr.Problems)
+	}
+}
+
+func TestLoadActions(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	writeFile(t, ConfigFileName, `targets:
+  - name: api
+    type: phpunit
+actions:
+  - key: u
+    label: update snapshots
+    scope: file
+    command: "npx vitest run -u {files}"
+    targets: [vitest]
+  - key: c
+    command: "./vendor/bin/phpunit --coverage-text --teamcity {files}"
+`)
+	writeFile(t, "web/"+ConfigFileName, `targets:
+  - name: vitest
+    type: vitest
+`)
+
+	cfg, err := Load(ConfigFileName)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	want := []Action{
+		{Key: "u", Label: "update snapshots", Scope: "file", Command: "npx vitest run -u {files}", Targets: []string{"vitest"}},
+		{Key: "c", Label: "./vendor/bin/phpunit --coverage-text --teamcity {files}", Scope: "run", Command: "./vendor/bin/phpunit --coverage-text --teamcity {files}"},
+	}
+	if !reflect.DeepEqual(cfg.Actions, want) {
+		t.Errorf("Actions = %+v, want %+v", cfg.Actions, want)
+	}
+
+	api, web := cfg.Targets[0], cfg.Targets[1]
+	if cfg.Actions[0].AppliesTo(api) || !cfg.Actions[0].AppliesTo(web) {
+		t.Errorf("update snapshots should apply to %s only", web.Name)
+	}
+	if !cfg.Actions[1].AppliesTo(api) || !cfg.Actions[1].AppliesTo(web) {
+		t.Errorf("an action without targets should apply to every target")
+	}
+}
+
+func TestLoadReportsActionProblems(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ConfigFileName)
+	writeFile(t, path, `targets:
+  - name: vitest
+    type: vitest
+actions:
+  - label: no key
+    command: "npx vitest run {files}"
+  - key: u
+    scope: suite
+    command: "npx vitest run -u {files} -t {test}"
+  - key: u
+    scope: test
+    command: "npx vitest run {file} -t {test} {coverage}"
+    targets: [vitest, jest]
+  - key: x
+`)
+
+	_, err := Load(path)
+	cfgErr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("Load error = %v, want *Error", err)
+	}
+	want := []Problem{
+		{Line: 5, Column: 5, Message: `action has no key`},
+		{Line: 8, Column: 12, Message: `unknown scope "suite" (supported: file, test, target, run)`},
+		{Line: 9, Column: 14, Message: `command: {test} is only available to actions with scope test`},
+		{Line: 10, Column: 10, Message: `key "u" is already bound to the action on line 7`},
+		{Line: 12, Column: 14, Message: `command: unknown placeholder {coverage} (supported: {files}, {file}, {dirs}, {names}, {reporter}, {test})`},
+		{Line: 13, Column: 23, Message: `targets: no target is named "jest" or has that type`},
+		{Line: 14, Column: 5, Message: `action has no command`},
+	}
+	if !reflect.DeepEqual(cfgErr.Problems, want) {
+		t.Errorf("problems = %+v, want %+v", cfgErr.Problems, want)
+	}
+}
+
+func TestCheckActionKeys(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ConfigFileName)
+	writeFile(t, path, `targets:
+  - name: vitest
+    type: vitest
+actions:
+  - key: u
+    command: "npx vitest run -u {files}"
+  - key: r
+    command: "npx vitest run --coverage {files}"
+`)
+
+	problems, err := CheckActionKeys(path, map[string]string{"r": "rerun", "j": "down"})
+	if err != nil {
+		t.Fatalf("CheckActionKeys error: %v", err)
+	}
+	want := []Problem{
+		{Line: 7, Column: 10, Message: `key "r" is bound to rerun in the results view, so the action never runs`, Warning: true},
+	}
+	if !reflect.DeepEqual(problems, want) {
+		t.Errorf("problems = %+v, want %+v", problems, want)
+	}
+}
+
+func TestLoadMatrix(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ConfigFileName)
+	writeFile(t, path, `targets:
+  - name: phpunit
+    type: phpunit
+    command: "docker compose run --rm $PHP_SERVICE ./vendor/bin/phpunit --teamcity {files}"
+    env:
+      APP_ENV: testing
+    matrix:
+      - name: php8.2
+        env:
+          PHP_SERVICE: php82
+      - name: php8.3
+        env:
+          PHP_SERVICE: php83
+          APP_ENV: ci
+      - name: local
+        command: "./vendor/bin/phpunit --teamcity {files}"
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	runs := cfg.Targets[0].Runs()
+	if len(runs) != 3 {
+		t.Fatalf("Runs length = %d, want 3", len(runs))
+	}
+	if runs[0].Name != "phpunit[php8.2]" || runs[0].Command != cfg.Targets[0].Command ||
+		!reflect.DeepEqual(runs[0].Env, map[string]string{"APP_ENV": "testing", "PHP_SERVICE": "php82"}) {
+		t.Errorf("Runs[0] = %+v", runs[0])
+	}
+	if !reflect.DeepEqual(runs[1].Env, map[string]string{"APP_ENV": "ci", "PHP_SERVICE": "php83"}) {
+		t.Errorf("Runs[1].Env = %v", runs[1].Env)
+	}
+	if runs[2].Name != "phpunit[local]" || runs[2].Command != "./vendor/bin/phpunit --teamcity {files}" ||
+		!reflect.DeepEqual(runs[2].Env, map[string]string{"APP_ENV": "testing"}) {
+		t.Errorf("Runs[2] = %+v", runs[2])
+	}
+	for _, r := range runs {
+		if r.Matrix != nil {
+			t.Errorf("run %s keeps the matrix", r.Name)
+		}
+	}
+	if cfg.Targets[0].Env["PHP_SERVICE"] != "" {
+		t.Errorf("variant env leaked into the target: %v", cfg.Targets[0].Env)
+	}
+
+	if runs := (Target{Name: "vitest"}).Runs(); len(runs) != 1 || runs[0].Name != "vitest" {
+		t.Errorf("Runs of a target without matrix = %+v", runs)
+	}
+}
+
+func TestLoadReportsMatrixProblems(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ConfigFileName)
+	writeFile(t, path, `targets:
+  - name: vitest
+    type: vitest
+    matrix:
+      - env: {NODE_VERSION: "20"}
+      - name: node[20]
+      - name: node22
+      - name: node22
+        command: "npx vitest run"
+  - name: web[legacy]
+    type: jest
+`)
+
+	_, err := Load(path)
+	cfgErr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("Load error = %v, want *Error", err)
+	}
+	want := []Problem{
+		{Line: 5, Column: 9, Message: `matrix variant has no name`},
+		{Line: 6, Column: 15, Message: `matrix variant name "node[20]" must not contain brackets`},
+		{Line: 8, Column: 15, Message: `duplicate matrix variant "node22" (first defined on line 7)`},
+		{Line: 10, Column: 11, Message: `target name "web[legacy]" must not contain brackets, which mark matrix variants`},
+	}
+	if !reflect.DeepEqual(cfgErr.Problems, want) {
+		t.Errorf("problems = %+v, want %+v", cfgErr.Problems, want)
+	}
+}
+
+func TestPathMapper(t *testing.T) {
+	pm, err := CompilePathMapper([]PathRule{
+		{Host: "server/src/", Runner: "/var/www/html/"},
+		{Host: `packages/([a-z]+)/`, Runner: `/app/([a-z]+)/`, Regex: true},
+	})
+	if err != nil {
+		t.Fatalf("CompilePathMapper error: %v", err)
+	}
+
+	for _, tt := range []struct{ host, runner string }{
+		{"server/src/tests/FooTest.php", "/var/www/html/tests/FooTest.php"},
+		{"packages/web/src/a.test.ts", "/app/web/src/a.test.ts"},
+	} {
+		if got, ok := pm.ToRunner(tt.host); !ok || got != tt.runner {
+			t.Errorf("ToRunner(%q) = %q, %v, want %q", tt.host, got, ok, tt.runner)
+		}
+	}
+	if got, ok := pm.ToRunner("tests/BarTest.php"); ok || got != "tests/BarTest.php" {
+		t.Errorf("ToRunner of an unmapped path = %q, %v", got, ok)
+	}
+
+	trace := "Failed asserting that false is true.\n/var/www/html/tests/FooTest.php:12\n/app/web/src/a.test.ts:3:7"
+	want := "Failed asserting that false is true.\nserver/src/tests/FooTest.php:12\npackages/web/src/a.test.ts:3:7"
+	if got := pm.ToHost(trace); got != want {
+		t.Errorf("ToHost = %q, want %q", got, want)
+	}
+}
+
+func TestPathMapperProjectRoot(t *testing.T) {
+	for _, host := range []string{"", "."} {
+		pm, err := CompilePathMapper([]PathRule{{Host: host, Runner: "/var/www/html/"}})
+		if err != nil {
+			t.Fatalf("CompilePathMapper with host %q error: %v", host, err)
+		}
+		if got, ok := pm.ToRunner("tests/FooTest.php"); !ok || got != "/var/www/html/tests/FooTest.php" {
+			t.Errorf("host %q: ToRunner = %q, %v, want /var/www/html/tests/FooTest.php", host, got, ok)
+		}
+		if got := pm.ToHost("at /var/www/html/tests/FooTest.php:12"); got != "at tests/FooTest.php:12" {
+			t.Errorf("host %q: ToHost = %q, want %q", host, got, "at tests/FooTest.php:12")
+		}
+	}
+}
+
+func TestLoadPathMap(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	writeFile(t, ConfigFileName, `targets:
+  - name: backend
+    type: phpunit
+    path_map:
+      - host: server/src/
+        runner: /var/www/html/
+`)
+	writeFile(t, "web/"+ConfigFileName, `targets:
+  - name: vitest
+    type: vitest
+    path_map:
+      - host: src/
+        runner: /app/src/
+      - host: 'lib/(\w+)/'
+        runner: '/app/lib/(\w+)/'
+        regex: true
+      - host: .
+        runner: /pkg/
+`)
+
+	cfg, err := Load(ConfigFileName)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if want := []PathRule{{Host: "server/src/", Runner: "/var/www/html/"}}; !reflect.DeepEqual(cfg.Targets[0].PathMap, want) {
+		t.Errorf("backend PathMap = %+v, want %+v", cfg.Targets[0].PathMap, want)
+	}
+	pm, err := CompilePathMapper(cfg.Targets[1].PathMap)
+	if err != nil {
+		t.Fatalf("CompilePathMapper error: %v", err)
+	}
+	for host, runner := range map[string]string{
+		"web/src/a.test.ts":      "/app/src/a.test.ts",
+		"web/lib/util/b.test.ts": "/app/lib/util/b.test.ts",
+		"web/test/c.test.ts":     "/pkg/test/c.test.ts",
+	} {
+		if got, _ := pm.ToRunner(host); got != runner {
+			t.Errorf("ToRunner(%q) = %q, want %q", host, got, runner)
+		}
+		if got := pm.ToHost(runner); got != host {
+			t.Errorf("ToHost(%q) = %q, want %q", runner, got, host)
+		}
+	}
+}
+
+func TestLoadReportsPathMapProblems(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ConfigFileName)
+	writeFile(t, path, `targets:
+  - name: backend
+    type: phpunit
+    path_map:
+      - host: server/src/
+      - host: 'packages/(\w+)/'
+        runner: '/app/'
+        regex: true
+      - host: 'packages/(\w+)/'
+        runner: '/app/.*/(\w+)/'
+        regex: true
+      - host: 'packages/(\w+/'
+        runner: '/app/(\w+)/'
+        regex: true
+`)
+
+	_, err := Load(path)
+	cfgErr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("Load error = %v, want *Error", err)
+	}
+	want := []Problem{
+		{Line: 5, Column: 9, Message: `path_map: a path_map rule needs a runner`},
+		{Line: 6, Column: 9, Message: `path_map: host and runner have different numbers of groups (1 and 0)`},
+		{Line: 9, Column: 9, Message: `path_map: runner: "/app/.*/(\\w+)/" must be literal text and (groups) only, to map paths back`},
+		{Line: 12, Column: 9, Message: "path_map: host: error parsing regexp: missing closing ): `^(?:packages/(\\w+/)`"},
+	}
+	if !reflect.DeepEqual(cfgErr.Problems, want) {
+		t.Errorf("problems = %+v, want %+v", cfgErr.Problems, want)
+	}
+}
@@ -13,52 +13,49 @@ var skipDirs = map[string]bool{
 	".git":         true,
 }
 
-// DetectFrameworks scans root (up to 3 levels deep) for known test frameworks
-// and returns a Target for each one found.
+// DetectFrameworks scans root for known test frameworks and returns a Target
+// for each package found. When root declares a workspace (npm/yarn/pnpm
+// workspaces, nx projects or Composer path repositories) its packages are
+// inspected, including frameworks declared only as dependencies. Nested
+// projects outside the workspace packages are found by walking up to 3
+// levels deep. Vitest and Jest targets take the settings QueryFrameworks
+// cached for them, if any.
 func DetectFrameworks(root string) ([]Target, error) {
-	var targets []Target
+	ws, isWorkspace := readWorkspace(root)
 
-	// Check root level for phpunit
-	if t, found := detectPHPUnitTarget(root, ""); found {
-		targets = append(targets, t)
-	}
-
-	// Check root level for vitest
-	if t, found := detectVitestTarget(root, ""); found {
-		targets = append(targets, t)
-	}
+	// A workspace root usually only hosts tooling, so its dependencies don't
+	// imply a test target of its own; its config files still do.
+	targets := detectPackageTargets(root, "", ws, !isWorkspace)
 
-	// Check root level for jest
-	if t, found := detectJestTarget(root, ""); found {
-		targets = append(targets, t)
-	}
-
-	// Walk up to 3 levels deep for nested projects
-	err := walkLimited(root, 3, func(dir, rel string) {
-		if t, found := detectPHPUnitTarget(dir, rel); found {
+	add := func(rel string, useDeps bool) {
+		for _, t := range detectPackageTargets(root, rel, ws, useDeps) {
 			// Avoid duplicates if already found at root
-			if !hasTarget(targets, "phpunit", dir) {
-				targets = append(targets, t)
-			}
-		}
-		if t, found := detectVitestTarget(dir, rel); found {
-			if !hasTarget(targets, "vitest", dir) {
+			if !hasTarget(targets, t.Type, rel) {
 				targets = append(targets, t)
 			}
 		}
-		if t, found := detectJestTarget(dir, rel); found {
-			if !hasTarget(targets, "jest", dir) {
-				targets = append(targets, t)
-			}
+	}
+
+	for _, rel := range ws.packages {
+		add(rel, true)
+	}
+	// Walk up to 3 levels deep for nested projects, such as a backend next
+	// to the JavaScript workspace.
+	err := walkLimited(root, 3, func(dir, rel string) {
+		if rel = filepath.ToSlash(rel); !ws.covers(rel) {
+			add(rel, false)
 		}
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	refineFromFramework(root, targets, false)
+	nameDuplicateTargets(targets)
+
 	// If nothing was found, return a default phpunit target
 	if len(targets) == 0 {
-		t := Target{Name: "phpunit"}
+		t := Target{Name: "phpunit", Type: "phpunit"}
 		t.applyDefaults()
 		targets = append(targets, t)
 	}
@@ -125,21 +122,51 @@ func detectPHPUnitTarget(dir, relFromRoot string) (Target, bool) {
 		return Target{}, false
 	}
 
-	dirs := parsePHPUnitDirs(data)
+	pc := parsePHPUnitConfig(data)
 
-	// Normalize test_dirs relative to root
+	// Normalize paths relative to root
+	prefix := func(p string) string {
+		if relFromRoot == "" {
+			return p
+		}
+		return filepath.ToSlash(filepath.Join(relFromRoot, p))
+	}
+	dirs := pc.Dirs
 	if relFromRoot != "" {
 		for i, d := range dirs {
-			dirs[i] = filepath.ToSlash(filepath.Join(relFromRoot, d))
+			dirs[i] = prefix(d)
 			if dirs[i][len(dirs[i])-1] != '/' {
 				dirs[i] += "/"
 			}
 		}
 	}
 
+	pattern := pc.FilePattern
+	if relFromRoot != "" && strings.Contains(pattern, "/") {
+		parts := strings.Split(pattern, ",")
+		for i, p := range parts {
+			parts[i] = prefix(p)
+		}
+		pattern = strings.Join(parts, ",")
+	}
+
+	var files, excludes []string
+	for _, f := range pc.Files {
+		files = append(files, prefix(f))
+	}
+	for _, e := range pc.Excludes {
+		// Anchor to the project root so "tests/Fixtures" doesn't match elsewhere.
+		excludes = append(excludes, "/"+strings.TrimPrefix(prefix(e), "/"))
+	}
+
 	t := Target{
-		Name:     "phpunit",
-		TestDirs: dirs,
+		Name:        "phpunit",
+		Type:        "phpunit",
+		TestDirs:    dirs,
+		FilePattern: pattern,
+		Files:       files,
+		Exclude:     excludes,
+		Suites:      pc.Suites,
 	}
 	if relFromRoot != "" {
 		t.WorkingDir = relFromRoot + "/"
@@ -163,6 +190,7 @@ func detectVitestTarget(dir, relFromRoot string) (Target, bool) {
 
 	t := Target{
 		Name: "vitest",
+		Type: "vitest",
 	}
 	if relFromRoot != "" {
 		t.WorkingDir = relFromRoot + "/"
@@ -187,6 +215,7 @@ func detectJestTarget(dir, relFromRoot string) (Target, bool) {
 
 	t := Target{
 		Name: "jest",
+		Type: "jest",
 	}
 	if relFromRoot != "" {
 		t.WorkingDir = relFromRoot + "/"
@@ -196,11 +225,50 @@ func detectJestTarget(dir, relFromRoot string) (Target, bool) {
 	return t, true
 }
 
-func hasTarget(targets []Target, name, dir string) bool {
+// hasTarget reports whether a target of the type was already detected in
+// the directory rel (relative to the root).
+func hasTarget(targets []Target, typ, rel string) bool {
 	for _, t := range targets {
-		if t.Name == name {
+		if t.Type == typ && t.WorkingDir == workingDirFor(rel) {
 			return true
 		}
 	}
 	return false
 }
+
+// workingDirFor returns the WorkingDir a detector assigns to rel.
+func workingDirFor(rel string) string {
+	if rel == "" {
+		return ""
+	}
+	return filepath.ToSlash(rel) + "/"
+}
+
+// nameDuplicateTargets gives each package of a framework that was detected
+// more than once a unique "<framework>:<package>" name. The root package
+// keeps the bare framework name. Packages are identified by their directory
+// name, or by their full path when directory names collide.
+func nameDuplicateTargets(targets []Target) {
+	count := make(map[string]int)
+	for _, t := range targets {
+		count[t.Name]++
+	}
+
+	baseCount := make(map[string]int)
+	for _, t := range targets {
+		if count[t.Name] > 1 && t.WorkingDir != "" {
+			baseCount[t.Name+":"+filepath.Base(t.WorkingDir)]++
+		}
+	}
+
+	for i, t := range targets {
+		if count[t.Name] <= 1 || t.WorkingDir == "" {
+			continue
+		}
+		name := t.Name + ":" + filepath.Base(t.WorkingDir)
+		if baseCount[name] > 1 {
+			name = t.Name + ":" + strings.TrimSuffix(t.WorkingDir, "/")
+		}
+		targets[i].Name = name
+	}
+}
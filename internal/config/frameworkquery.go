@@ -0,0 +1,286 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/meijin/lazytest/internal/state"
+)
+
+const (
+	frameworkCacheFileName = "framework-tests.json"
+
+	// frameworkQueryTimeout bounds each call to a framework's list command.
+	frameworkQueryTimeout = 10 * time.Second
+)
+
+// frameworkQueries lists, per framework, the local binary to ask and the
+// argument lists to try in order. Each must print a JSON array of test files
+// (plain paths, or objects with a "file" field).
+var frameworkQueries = map[string]struct {
+	bin  string
+	args [][]string
+}{
+	"vitest": {bin: "vitest", args: [][]string{
+		{"list", "--filesOnly", "--json"},
+		{"list", "--json"},
+	}},
+	"jest": {bin: "jest", args: [][]string{
+		{"--listTests", "--json"},
+	}},
+}
+
+// runFrameworkQuery runs a framework binary in dir and returns its stdout.
+// It is a variable so tests can stub it.
+var runFrameworkQuery = func(dir, bin string, args []string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), frameworkQueryTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.Dir = dir
+	return cmd.Output()
+}
+
+// frameworkCacheEntry is the derived scan settings for one detected target.
+type frameworkCacheEntry struct {
+	Fingerprint string   `json:"fingerprint"`
+	TestDirs    []string `json:"test_dirs"`
+	FilePattern string   `json:"file_pattern"`
+}
+
+// QueryFrameworks replaces the guessed TestDirs/FilePattern of the Vitest
+// and Jest targets DetectFrameworks found in root with settings derived from
+// the test files the framework itself reports. Only locally installed
+// binaries are used, and results are cached in the project's state dir
+// until the package's config files change, for DetectFrameworks to pick up.
+// Targets whose framework can't be queried are left as is. The query runs
+// project code and can take seconds, so it is left to "lazytest init" and
+// to the background of a zero-config start.
+func QueryFrameworks(root string, targets []Target) {
+	refineFromFramework(root, targets, true)
+}
+
+// refineFromFramework applies the cached framework settings to targets and,
+// with ask set, asks the frameworks whose cache entry is missing or stale.
+func refineFromFramework(root string, targets []Target, ask bool) {
+	var cache map[string]frameworkCacheEntry
+	var cachePath string
+	dirty := false
+
+	for i := range targets {
+		t := &targets[i]
+		query, ok := frameworkQueries[t.Framework()]
+		if !ok {
+			continue
+		}
+		pkgDir := filepath.Join(root, filepath.FromSlash(t.WorkingDir))
+		bin := findLocalBin(root, pkgDir, query.bin)
+		if bin == "" {
+			continue
+		}
+
+		if cache == nil {
+			cache, cachePath = loadFrameworkCache(root)
+		}
+		key := t.Framework() + "\x00" + t.WorkingDir
+		fingerprint := packageFingerprint(pkgDir)
+		if entry, ok := cache[key]; ok && entry.Fingerprint == fingerprint {
+			t.TestDirs, t.FilePattern = entry.TestDirs, entry.FilePattern
+			continue
+		}
+		if !ask {
+			continue
+		}
+
+		var files []string
+		for _, args := range query.args {
+			out, err := runFrameworkQuery(pkgDir, bin, args)
+			if err != nil {
+				continue
+			}
+			if files = parseTestList(out); files != nil {
+				break
+			}
+		}
+		dirs, pattern := deriveScanSettings(root, pkgDir, t.WorkingDir, files)
+		if len(dirs) == 0 || pattern == "" {
+			continue
+		}
+
+		t.TestDirs, t.FilePattern = dirs, pattern
+		cache[key] = frameworkCacheEntry{Fingerprint: fingerprint, TestDirs: dirs, FilePattern: pattern}
+		dirty = true
+	}
+
+	if dirty && cachePath != "" {
+		if data, err := json.MarshalIndent(cache, "", "  "); err == nil {
+			if os.MkdirAll(filepath.Dir(cachePath), 0755) == nil {
+				state.WriteFileAtomic(cachePath, data)
+			}
+		}
+	}
+}
+
+// findLocalBin looks for node_modules/.bin/name in pkgDir and its parents up
+// to root (hoisted installs), returning "" if it isn't installed.
+func findLocalBin(root, pkgDir, name string) string {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return ""
+	}
+	dir, err := filepath.Abs(pkgDir)
+	if err != nil {
+		return ""
+	}
+	for {
+		bin := filepath.Join(dir, "node_modules", ".bin", name)
+		if info, err := os.Stat(bin); err == nil && !info.IsDir() {
+			return bin
+		}
+		if dir == absRoot {
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func loadFrameworkCache(root string) (map[string]frameworkCacheEntry, string) {
+	cache := make(map[string]frameworkCacheEntry)
+	dir, err := state.Path(root)
+	if err != nil {
+		return cache, ""
+	}
+	p := filepath.Join(dir, frameworkCacheFileName)
+	if data, err := os.ReadFile(p); err == nil {
+		json.Unmarshal(data, &cache)
+	}
+	return cache, p
+}
+
+// packageFingerprint summarizes the files that determine which tests a
+// JavaScript package has, so cached results are dropped when they change.
+func packageFingerprint(pkgDir string) string {
+	var parts []string
+	entries, _ := os.ReadDir(pkgDir)
+	for _, e := range entries {
+		name := e.Name()
+		if name != "package.json" && !strings.HasPrefix(name, "vitest.") &&
+			!strings.HasPrefix(name, "vite.config.") && !strings.HasPrefix(name, "jest.config.") {
+			continue
+		}
+		if info, err := e.Info(); err == nil {
+			parts = append(parts, name+"@"+info.ModTime().UTC().Format(time.RFC3339Nano))
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+// parseTestList extracts file paths from a framework's JSON test list. Any
+// log output before the JSON array is ignored.
+func parseTestList(out []byte) []string {
+	start := bytes.IndexByte(out, '[')
+	if start < 0 {
+		return nil
+	}
+	var raw []json.RawMessage
+	if err := json.Unmarshal(out[start:], &raw); err != nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	files := []string{}
+	for _, r := range raw {
+		var file string
+		var obj struct {
+			File string `json:"file"`
+		}
+		if json.Unmarshal(r, &file) != nil {
+			if json.Unmarshal(r, &obj) != nil {
+				continue
+			}
+			file = obj.File
+		}
+		if file != "" && !seen[file] {
+			seen[file] = true
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// deriveScanSettings turns the framework's test files into TestDirs (the
+// top-level directories of the package that contain tests) and a
+// FilePattern (e.g. "*.spec.ts" or "**/__tests__/**/*.ts") that matches them.
+func deriveScanSettings(root, pkgDir, workingDir string, files []string) ([]string, string) {
+	absPkg, err := filepath.Abs(pkgDir)
+	if err != nil {
+		return nil, ""
+	}
+
+	dirSet := make(map[string]bool)
+	patternSet := make(map[string]bool)
+	for _, f := range files {
+		if !filepath.IsAbs(f) {
+			f = filepath.Join(absPkg, f)
+		}
+		rel, err := filepath.Rel(absPkg, f)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+
+		if i := strings.IndexByte(rel, '/'); i >= 0 {
+			dirSet[workingDir+rel[:i+1]] = true
+		} else {
+			dirSet[workingDirOrDot(workingDir)] = true
+		}
+		patternSet[filePatternFor(rel)] = true
+	}
+
+	if dirSet[workingDirOrDot(workingDir)] {
+		dirSet = map[string]bool{workingDirOrDot(workingDir): true}
+	}
+	return sortedKeys(dirSet), strings.Join(sortedKeys(patternSet), ",")
+}
+
+// filePatternFor generalizes a test file path into a file pattern.
+func filePatternFor(rel string) string {
+	base := path.Base(rel)
+	for _, marker := range []string{".test.", ".spec."} {
+		if i := strings.Index(base, marker); i >= 0 {
+			return "*" + base[i:]
+		}
+	}
+	if strings.Contains("/"+rel, "/__tests__/") {
+		return "**/__tests__/**/*" + path.Ext(base)
+	}
+	return base
+}
+
+func workingDirOrDot(workingDir string) string {
+	if workingDir == "" {
+		return "./"
+	}
+	return workingDir
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
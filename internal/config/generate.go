@@ -0,0 +1,203 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// generatedHeader opens a config file written by Generate.
+const generatedHeader = `LazyTest configuration, generated by "lazytest init".
+
+Each target runs one test framework. Keys left out get defaults from the
+target type, and "lazytest config check" reports mistakes. Command
+placeholders: {files} the selected files, {file} the first one, {dirs}
+their directories, {names} their names without extension and {reporter}
+the built-in reporter (vitest, jest, rspec and pytest).`
+
+// Generate renders targets as a commented .lazytest.yml.
+func Generate(targets []Target) ([]byte, error) {
+	items := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, t := range targets {
+		items.Content = append(items.Content, targetNode(t))
+	}
+	root := &yaml.Node{Kind: yaml.MappingNode, HeadComment: generatedHeader}
+	root.Content = []*yaml.Node{scalarNode("targets"), items}
+	return encodeYAML(root)
+}
+
+// Unconfigured returns the detected targets that the config at configPath
+// and its package configs don't have yet: those whose type and working dir
+// match no configured target. Names already taken are made unique. It also
+// returns the names of the configured targets.
+func Unconfigured(configPath string, detected []Target) (added []Target, taken []string, err error) {
+	// Problems don't matter here, only which targets exist.
+	cfg, _, err := loadConfig(configPath, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	names := make(map[string]bool)
+	for _, t := range cfg.Targets {
+		names[t.Name] = true
+		taken = append(taken, t.Name)
+	}
+
+	for _, d := range detected {
+		configured := false
+		for _, t := range cfg.Targets {
+			if t.Framework() == d.Type && path.Clean("./"+t.WorkingDir) == path.Clean("./"+d.WorkingDir) {
+				configured = true
+				break
+			}
+		}
+		if configured {
+			continue
+		}
+		d.Name = uniqueName(d, names)
+		names[d.Name] = true
+		added = append(added, d)
+	}
+	return added, taken, nil
+}
+
+// uniqueName returns t's name, or a variant of it not in taken.
+func uniqueName(t Target, taken map[string]bool) string {
+	name := t.Name
+	if taken[name] && t.WorkingDir != "" && !strings.Contains(name, ":") {
+		name += ":" + path.Base(t.WorkingDir)
+	}
+	base := name
+	for i := 2; taken[name]; i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+	return name
+}
+
+// AppendTargets adds targets to the end of the targets list in data, the
+// contents of an existing config file. When the list is the last thing in
+// the file the new entries are appended as text, so everything already in
+// the file stays byte for byte; otherwise the file is re-encoded, which
+// keeps its comments but normalizes indentation.
+func AppendTargets(data []byte, targets []Target) ([]byte, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return Generate(targets)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("config is not a mapping")
+	}
+	root := doc.Content[0]
+
+	items := valueNode(root, "targets")
+	if items == nil {
+		items = &yaml.Node{Kind: yaml.SequenceNode}
+		root.Content = append(root.Content, scalarNode("targets"), items)
+	}
+	if items.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("targets is not a list")
+	}
+
+	last := root.Content[len(root.Content)-1]
+	if last == items && items.Style&yaml.FlowStyle == 0 && len(items.Content) > 0 {
+		seq := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, t := range targets {
+			seq.Content = append(seq.Content, targetNode(t))
+		}
+		text, err := encodeYAML(seq)
+		if err != nil {
+			return nil, err
+		}
+		indent := strings.Repeat(" ", items.Content[0].Column-3)
+		var out bytes.Buffer
+		out.Write(data)
+		if !bytes.HasSuffix(data, []byte("\n")) {
+			out.WriteByte('\n')
+		}
+		for _, line := range strings.SplitAfter(string(text), "\n") {
+			if strings.TrimSpace(line) != "" {
+				out.WriteString(indent)
+			}
+			out.WriteString(line)
+		}
+		return out.Bytes(), nil
+	}
+
+	for _, t := range targets {
+		items.Content = append(items.Content, targetNode(t))
+	}
+	items.Style = 0
+	return encodeYAML(&doc)
+}
+
+// targetNode renders t as a mapping, leaving out empty fields.
+func targetNode(t Target) *yaml.Node {
+	n := &yaml.Node{Kind: yaml.MappingNode}
+	if t.WorkingDir != "" {
+		n.HeadComment = "Detected in " + t.WorkingDir
+	} else {
+		n.HeadComment = "Detected in the project root"
+	}
+
+	add := func(key string, value *yaml.Node) {
+		n.Content = append(n.Content, scalarNode(key), value)
+	}
+	addString := func(key, value string) {
+		if value != "" {
+			add(key, scalarNode(value))
+		}
+	}
+	addList := func(key string, values []string) {
+		if len(values) == 0 {
+			return
+		}
+		seq := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, v := range values {
+			seq.Content = append(seq.Content, scalarNode(v))
+		}
+		add(key, seq)
+	}
+
+	addString("name", t.Name)
+	addString("type", t.Type)
+	addString("command", t.Command)
+	addList("test_dirs", t.TestDirs)
+	addString("file_pattern", t.FilePattern)
+	addList("exclude", t.Exclude)
+	addList("files", t.Files)
+	addList("suites", t.Suites)
+	addString("path_strip_prefix", t.PathStripPrefix)
+	addString("working_dir", t.WorkingDir)
+	return n
+}
+
+func scalarNode(value string) *yaml.Node {
+	n := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+	// Quote values YAML would otherwise read as something else, and
+	// commands and patterns, which often contain characters like {, * or #.
+	var v any
+	if yaml.Unmarshal([]byte(value), &v) != nil || fmt.Sprint(v) != value || strings.ContainsAny(value, "{}*#:") {
+		n.Style = yaml.DoubleQuotedStyle
+	}
+	return n
+}
+
+func encodeYAML(n *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(n); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
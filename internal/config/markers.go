@@ -0,0 +1,75 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+)
+
+// markerFramework is a framework detected by the presence of a marker file
+// in a package directory.
+type markerFramework struct {
+	name  string
+	files []string
+	// accept, if set, must approve the marker file's content.
+	accept func(data []byte) bool
+}
+
+// markerFrameworks lists the frameworks detected from marker files alone.
+// Their targets take all other settings from Target.applyDefaults.
+var markerFrameworks = []markerFramework{
+	{name: "pytest", files: []string{"pytest.ini", "pyproject.toml"}, accept: mentionsPytest},
+	{name: "go", files: []string{"go.mod"}},
+	{name: "cargo", files: []string{"Cargo.toml"}},
+	{name: "rspec", files: []string{".rspec"}},
+	{name: "bun", files: []string{"bunfig.toml"}},
+	{name: "deno", files: []string{"deno.json", "deno.jsonc"}},
+}
+
+// mentionsPytest reports whether a pytest.ini or pyproject.toml configures
+// or depends on pytest. A pyproject.toml for a package without tests
+// shouldn't produce a target.
+func mentionsPytest(data []byte) bool {
+	return bytes.Contains(data, []byte("pytest"))
+}
+
+// find returns the first of m's marker files present in dir.
+func (m markerFramework) find(dir string) (string, bool) {
+	for _, name := range m.files {
+		p := filepath.Join(dir, name)
+		info, err := os.Stat(p)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		if m.accept != nil {
+			data, err := os.ReadFile(p)
+			if err != nil || !m.accept(data) {
+				continue
+			}
+		}
+		return name, true
+	}
+	return "", false
+}
+
+// detectMarkerTargets returns a target for each marker framework found in
+// the package dir rel (relative to the root), using the package dir itself
+// as base for the default test dirs.
+func detectMarkerTargets(dir, rel string) []Target {
+	var targets []Target
+	for _, m := range markerFrameworks {
+		if _, ok := m.find(dir); !ok {
+			continue
+		}
+		t := Target{Name: m.name, Type: m.name}
+		t.applyDefaults()
+		if rel != "" {
+			t.WorkingDir = workingDirFor(rel)
+			for i, d := range t.TestDirs {
+				t.TestDirs[i] = filepath.ToSlash(filepath.Join(rel, d)) + "/"
+			}
+		}
+		targets = append(targets, t)
+	}
+	return targets
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/meijin/lazytest/internal/domain"
+)
+
+// Variant is one environment of a target's matrix, e.g. a PHP or Node
+// version. Its runs are named "target[variant]".
+type Variant struct {
+	Name string `yaml:"name"`
+	// Env is added to the target's env for this variant.
+	Env map[string]string `yaml:"env"`
+	// Command replaces the target's command for this variant.
+	Command string `yaml:"command"`
+}
+
+// Runs returns what running t's files means: one target per matrix
+// variant, named after it and with its env and command, or t itself when
+// it has no matrix.
+func (t Target) Runs() []Target {
+	if len(t.Matrix) == 0 {
+		return []Target{t}
+	}
+	runs := make([]Target, len(t.Matrix))
+	for i, v := range t.Matrix {
+		run := t
+		run.Name = domain.RunName(t.Name, v.Name)
+		run.Matrix = nil
+		if v.Command != "" {
+			run.Command = v.Command
+		}
+		if len(v.Env) > 0 {
+			run.Env = make(map[string]string, len(t.Env)+len(v.Env))
+			for k, val := range t.Env {
+				run.Env[k] = val
+			}
+			for k, val := range v.Env {
+				run.Env[k] = val
+			}
+		}
+		runs[i] = run
+	}
+	return runs
+}
+
+// checkMatrix reports variants without a name, with a name that can't be
+// told apart in a run name, or with the name of another variant, and
+// problems in their commands.
+func checkMatrix(t Target, node *yaml.Node, add, warn func(*yaml.Node, string, ...any)) {
+	var variantNodes []*yaml.Node
+	if n := valueNode(node, "matrix"); n != nil && n.Kind == yaml.SequenceNode {
+		variantNodes = n.Content
+	}
+	firstLine := make(map[string]int)
+	for i, v := range t.Matrix {
+		vNode := node
+		if i < len(variantNodes) {
+			vNode = variantNodes[i]
+		}
+		switch line, dup := firstLine[v.Name]; {
+		case v.Name == "":
+			add(vNode, "matrix variant has no name")
+		case strings.ContainsAny(v.Name, "[]"):
+			add(nodeOr(vNode, "name"), "matrix variant name %q must not contain brackets", v.Name)
+		case dup:
+			add(nodeOr(vNode, "name"), "duplicate matrix variant %q (first defined on line %d)", v.Name, line)
+		default:
+			firstLine[v.Name] = nodeOr(vNode, "name").Line
+		}
+		if v.Command != "" {
+			run := t
+			run.Command = v.Command
+			checkCommand(run, nodeOr(vNode, "command"), add, warn)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maxPackageConfigDepth bounds the walk that discovers package configs when
+// the root config has no include list.
+const maxPackageConfigDepth = 3
+
+// pkgConfig locates the config of one package. dir is the package
+// directory relative to the current directory (the root config's paths are
+// relative to it too) and name prefixes the package's target names.
+type pkgConfig struct {
+	dir  string
+	name string
+}
+
+// file returns the path of the package's config file.
+func (p pkgConfig) file() string {
+	return filepath.Join(filepath.FromSlash(p.dir), ConfigFileName)
+}
+
+// loadConfig reads the config at configPath together with the package
+// configs it includes (or, without an include list, the ones found below
+// it), and returns the merged config and every problem found. checkFS
+// enables the checks that look for test dirs and working dirs.
+func loadConfig(configPath string, checkFS bool) (Config, []Problem, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return Config{}, nil, err
+	}
+
+	cfg, problems := parseConfig(data, pkgConfig{}, checkFS)
+	defined := make(map[string]string, len(cfg.Targets))
+	for _, t := range cfg.Targets {
+		defined[t.Name] = configPath
+	}
+
+	for _, pkg := range findPackageConfigs(filepath.Dir(configPath), cfg.Include) {
+		file := pkg.file()
+		data, err := os.ReadFile(file)
+		if err != nil {
+			problems = append(problems, Problem{File: file, Message: err.Error()})
+			continue
+		}
+		pc, pkgProblems := parseConfig(data, pkg, checkFS)
+		for i := range pkgProblems {
+			pkgProblems[i].File = file
+		}
+		problems = append(problems, pkgProblems...)
+		if len(pc.Include) > 0 {
+			problems = append(problems, Problem{File: file, Line: 1, Message: "include is only supported in the root config"})
+		}
+		if len(pc.Sets) > 0 {
+			problems = append(problems, Problem{File: file, Line: 1, Message: "sets are only supported in the root config"})
+		}
+		if len(pc.Actions) > 0 {
+			problems = append(problems, Problem{File: file, Line: 1, Message: "actions are only supported in the root config"})
+		}
+		for _, t := range pc.Targets {
+			if other, dup := defined[t.Name]; dup {
+				problems = append(problems, Problem{File: file, Message: fmt.Sprintf("duplicate target name %q (also defined in %s)", t.Name, other)})
+				continue
+			}
+			defined[t.Name] = file
+			cfg.Targets = append(cfg.Targets, t)
+		}
+	}
+
+	// Actions may name the targets of package configs, so they are checked
+	// once those are merged.
+	if len(cfg.Actions) > 0 {
+		problems = append(problems, actionProblems(data, cfg)...)
+		SortProblems(problems)
+	}
+
+	if len(cfg.Targets) == 0 && len(problems) == 0 {
+		problems = append(problems, Problem{Line: 1, Message: "no targets defined"})
+	}
+	return cfg, problems, nil
+}
+
+// findPackageConfigs returns the package configs of the project whose root
+// config is in dir: those in the directories matched by the include globs,
+// or every .lazytest.yml up to maxPackageConfigDepth levels below dir.
+// Packages are named after their directory, or its full path where two
+// directories share a name.
+func findPackageConfigs(dir string, include []string) []pkgConfig {
+	var rels []string
+	if len(include) > 0 {
+		var patterns []string
+		for _, p := range include {
+			p = strings.TrimSuffix(filepath.ToSlash(p), "/"+ConfigFileName)
+			patterns = append(patterns, p)
+		}
+		for _, rel := range expandWorkspaceGlobs(dir, patterns) {
+			if exists(filepath.Join(dir, filepath.FromSlash(rel), ConfigFileName)) {
+				rels = append(rels, rel)
+			}
+		}
+	} else {
+		walkLimited(dir, maxPackageConfigDepth, func(d, rel string) {
+			if exists(filepath.Join(d, ConfigFileName)) {
+				rels = append(rels, filepath.ToSlash(rel))
+			}
+		})
+	}
+	sort.Strings(rels)
+
+	baseCount := make(map[string]int)
+	for _, rel := range rels {
+		baseCount[path.Base(rel)]++
+	}
+	pkgs := make([]pkgConfig, len(rels))
+	for i, rel := range rels {
+		name := path.Base(rel)
+		if baseCount[name] > 1 {
+			name = rel
+		}
+		pkgs[i] = pkgConfig{dir: path.Join(filepath.ToSlash(dir), rel), name: name}
+	}
+	return pkgs
+}
+
+// rebase turns t, read from the config of pkg, into a target of the
+// project: its paths become relative to the project like those of root
+// targets, it runs in the package directory unless it sets a working dir,
+// and its name gets the package as prefix ("web/vitest").
+func (p pkgConfig) rebase(t *Target) {
+	join := func(rel string) string {
+		if path.IsAbs(rel) {
+			return rel
+		}
+		joined := path.Join(p.dir, rel)
+		if strings.HasSuffix(rel, "/") || rel == "" || rel == "." || rel == "./" {
+			joined += "/"
+		}
+		return joined
+	}
+
+	t.Name = p.name + "/" + t.Name
+	t.WorkingDir = join(t.WorkingDir)
+	for i, d := range t.TestDirs {
+		t.TestDirs[i] = join(d)
+	}
+	for i, f := range t.Files {
+		t.Files[i] = join(f)
+	}
+	if t.PathStripPrefix != "" {
+		t.PathStripPrefix = join(t.PathStripPrefix)
+	}
+	// Host paths of path_map rules are relative to the package too.
+	for i, r := range t.PathMap {
+		if r.Regex {
+			t.PathMap[i].Host = regexp.QuoteMeta(p.dir+"/") + strings.TrimPrefix(r.Host, "^")
+		} else {
+			t.PathMap[i].Host = join(r.Host)
+		}
+	}
+	// Anchored excludes are relative to the package; the others match
+	// anywhere and need no change.
+	for i, e := range t.Exclude {
+		if strings.HasPrefix(e, "/") {
+			t.Exclude[i] = "/" + join(strings.TrimPrefix(e, "/"))
+		}
+	}
+}
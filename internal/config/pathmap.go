@@ -0,0 +1,199 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PathRule maps paths between the host and the test runner, e.g. a
+// project dir mounted at /var/www/html in a container. Host is relative to
+// the project root, like test file paths; empty or "." is the root itself.
+// With Regex set, Host and Runner
+// are regular expressions whose groups carry over to the other side, e.g.
+// "packages/([^/]+)/" and "/app/([^/]+)/"; each side must be literal text
+// and groups only, so it can be filled in from the other.
+type PathRule struct {
+	Host   string `yaml:"host"`
+	Runner string `yaml:"runner"`
+	Regex  bool   `yaml:"regex"`
+}
+
+// PathMapper translates paths with the path_map rules of a target, in
+// order.
+type PathMapper []pathMapping
+
+// pathMapping is a compiled PathRule.
+type pathMapping struct {
+	host       *regexp.Regexp // anchored at the start of a host path
+	runner     *regexp.Regexp // found anywhere in runner output
+	hostTmpl   []pathPart
+	runnerTmpl []pathPart
+}
+
+// pathPart is literal text, or the value of capture group group when it
+// is not 0.
+type pathPart struct {
+	text  string
+	group int
+}
+
+// CompilePathMapper compiles rules, failing on the first invalid one.
+func CompilePathMapper(rules []PathRule) (PathMapper, error) {
+	m := make(PathMapper, 0, len(rules))
+	for _, r := range rules {
+		pm, err := compilePathRule(r)
+		if err != nil {
+			return nil, err
+		}
+		m = append(m, pm)
+	}
+	return m, nil
+}
+
+func compilePathRule(r PathRule) (pathMapping, error) {
+	if r.Runner == "" {
+		return pathMapping{}, fmt.Errorf("a path_map rule needs a runner")
+	}
+	if !r.Regex {
+		host := r.Host
+		if host == "." || host == "./" {
+			host = ""
+		}
+		return pathMapping{
+			host:       regexp.MustCompile("^" + regexp.QuoteMeta(host)),
+			runner:     regexp.MustCompile(regexp.QuoteMeta(r.Runner)),
+			hostTmpl:   []pathPart{{text: host}},
+			runnerTmpl: []pathPart{{text: r.Runner}},
+		}, nil
+	}
+
+	host, err := regexp.Compile("^(?:" + r.Host + ")")
+	if err != nil {
+		return pathMapping{}, fmt.Errorf("host: %v", err)
+	}
+	runner, err := regexp.Compile(r.Runner)
+	if err != nil {
+		return pathMapping{}, fmt.Errorf("runner: %v", err)
+	}
+	if host.NumSubexp() != runner.NumSubexp() {
+		return pathMapping{}, fmt.Errorf("host and runner have different numbers of groups (%d and %d)", host.NumSubexp(), runner.NumSubexp())
+	}
+	hostTmpl, err := pathTemplate(r.Host)
+	if err != nil {
+		return pathMapping{}, fmt.Errorf("host: %v", err)
+	}
+	runnerTmpl, err := pathTemplate(r.Runner)
+	if err != nil {
+		return pathMapping{}, fmt.Errorf("runner: %v", err)
+	}
+	return pathMapping{host: host, runner: runner, hostTmpl: hostTmpl, runnerTmpl: runnerTmpl}, nil
+}
+
+// pathTemplate turns a regular expression made of literal text and
+// capture groups into the parts that rebuild a matching path.
+func pathTemplate(expr string) ([]pathPart, error) {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+	nodes := []*syntax.Regexp{re}
+	if re.Op == syntax.OpConcat {
+		nodes = re.Sub
+	}
+	var parts []pathPart
+	for _, n := range nodes {
+		switch n.Op {
+		case syntax.OpLiteral:
+			parts = append(parts, pathPart{text: string(n.Rune)})
+		case syntax.OpCapture:
+			parts = append(parts, pathPart{group: n.Cap})
+		case syntax.OpBeginText, syntax.OpBeginLine, syntax.OpEmptyMatch:
+		default:
+			return nil, fmt.Errorf("%q must be literal text and (groups) only, to map paths back", expr)
+		}
+	}
+	return parts, nil
+}
+
+// fill builds the path of tmpl from the submatches loc of s.
+func fill(tmpl []pathPart, s string, loc []int) string {
+	var b strings.Builder
+	for _, p := range tmpl {
+		if p.group == 0 {
+			b.WriteString(p.text)
+		} else if start := loc[2*p.group]; start >= 0 {
+			b.WriteString(s[start:loc[2*p.group+1]])
+		}
+	}
+	return b.String()
+}
+
+// ToRunner translates a host path with the first rule whose host side it
+// starts with, and reports whether one did.
+func (m PathMapper) ToRunner(path string) (string, bool) {
+	for _, pm := range m {
+		if loc := pm.host.FindStringSubmatchIndex(path); loc != nil {
+			return fill(pm.runnerTmpl, path, loc) + path[loc[1]:], true
+		}
+	}
+	return path, false
+}
+
+// ToHost translates the runner paths anywhere in text, such as a stack
+// trace or a locationHint. Where rules overlap, the earliest match wins,
+// then the first rule.
+func (m PathMapper) ToHost(text string) string {
+	if len(m) == 0 {
+		return text
+	}
+	var b strings.Builder
+	for text != "" {
+		var best *pathMapping
+		var bestLoc []int
+		for i := range m {
+			loc := m[i].runner.FindStringSubmatchIndex(text)
+			if loc != nil && (bestLoc == nil || loc[0] < bestLoc[0]) {
+				best, bestLoc = &m[i], loc
+			}
+		}
+		if best == nil {
+			break
+		}
+		b.WriteString(text[:bestLoc[0]])
+		b.WriteString(fill(best.hostTmpl, text, bestLoc))
+		end := bestLoc[1]
+		if end == bestLoc[0] {
+			// An empty match maps nothing; step past it.
+			if end == len(text) {
+				text = ""
+				break
+			}
+			b.WriteString(text[end : end+1])
+			end++
+		}
+		text = text[end:]
+	}
+	b.WriteString(text)
+	return b.String()
+}
+
+// checkPathMap reports path_map rules that don't compile.
+func checkPathMap(t Target, node *yaml.Node, add func(*yaml.Node, string, ...any)) {
+	var ruleNodes []*yaml.Node
+	if n := valueNode(node, "path_map"); n != nil && n.Kind == yaml.SequenceNode {
+		ruleNodes = n.Content
+	}
+	for i, r := range t.PathMap {
+		n := node
+		if i < len(ruleNodes) {
+			n = ruleNodes[i]
+		}
+		if _, err := compilePathRule(r); err != nil {
+			add(n, "path_map: %v", err)
+		}
+	}
+}
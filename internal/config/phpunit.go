@@ -1,9 +1,13 @@
 package config
 
-import "encoding/xml"
+import (
+	"encoding/xml"
+	"path"
+	"strings"
+)
 
 type phpunitXML struct {
-	XMLName    xml.Name        `xml:"phpunit"`
+	XMLName    xml.Name         `xml:"phpunit"`
 	TestSuites phpunitSuitesXML `xml:"testsuites"`
 }
 
@@ -12,28 +16,87 @@ type phpunitSuitesXML struct {
 }
 
 type phpunitSuiteXML struct {
-	Name        string   `xml:"name,attr"`
-	Directories []string `xml:"directory"`
+	Name        string          `xml:"name,attr"`
+	Directories []phpunitDirXML `xml:"directory"`
+	Files       []string        `xml:"file"`
+	Excludes    []string        `xml:"exclude"`
 }
 
-// parsePHPUnitDirs extracts test directory paths from phpunit.xml content.
-func parsePHPUnitDirs(data []byte) []string {
+type phpunitDirXML struct {
+	Path   string `xml:",chardata"`
+	Prefix string `xml:"prefix,attr"`
+	Suffix string `xml:"suffix,attr"`
+}
+
+// phpunitConfig is the part of phpunit.xml that affects test discovery.
+type phpunitConfig struct {
+	Dirs        []string // test directories, with trailing slash
+	FilePattern string   // empty when every directory uses PHPUnit's default
+	Files       []string // explicitly listed test files
+	Excludes    []string // excluded paths
+	Suites      []string // names of the declared test suites
+}
+
+// defaultPHPUnitSuffix is the suffix PHPUnit uses when a <directory> has none.
+const defaultPHPUnitSuffix = "Test.php"
+
+// parsePHPUnitConfig extracts discovery settings from phpunit.xml content.
+// Directory suffix/prefix attributes become the file pattern: a single
+// pattern when all directories agree, otherwise one "<dir>**/<pattern>" glob
+// per directory.
+func parsePHPUnitConfig(data []byte) phpunitConfig {
 	var parsed phpunitXML
 	if err := xml.Unmarshal(data, &parsed); err != nil {
-		return nil
+		return phpunitConfig{}
 	}
 
-	var dirs []string
+	var cfg phpunitConfig
+	var dirPatterns []string
+	uniform := true
 	for _, suite := range parsed.TestSuites.Suites {
+		if suite.Name != "" {
+			cfg.Suites = append(cfg.Suites, suite.Name)
+		}
 		for _, d := range suite.Directories {
-			if d != "" {
-				// Ensure trailing slash
-				if d[len(d)-1] != '/' {
-					d += "/"
-				}
-				dirs = append(dirs, d)
+			p := strings.TrimSpace(d.Path)
+			if p == "" {
+				continue
+			}
+			// Ensure trailing slash
+			if p[len(p)-1] != '/' {
+				p += "/"
+			}
+			suffix := d.Suffix
+			if suffix == "" {
+				suffix = defaultPHPUnitSuffix
+			}
+			pattern := d.Prefix + "*" + suffix
+			if len(dirPatterns) > 0 && pattern != path.Base(dirPatterns[0]) {
+				uniform = false
+			}
+			cfg.Dirs = append(cfg.Dirs, p)
+			dirPatterns = append(dirPatterns, p+"**/"+pattern)
+		}
+		for _, f := range suite.Files {
+			if f = strings.TrimSpace(f); f != "" {
+				cfg.Files = append(cfg.Files, f)
+			}
+		}
+		for _, e := range suite.Excludes {
+			if e = strings.TrimSpace(e); e != "" {
+				cfg.Excludes = append(cfg.Excludes, e)
+			}
+		}
+	}
+
+	if len(dirPatterns) > 0 {
+		if uniform {
+			if p := path.Base(dirPatterns[0]); p != "*"+defaultPHPUnitSuffix {
+				cfg.FilePattern = p
 			}
+		} else {
+			cfg.FilePattern = strings.Join(dirPatterns, ",")
 		}
 	}
-	return dirs
+	return cfg
 }
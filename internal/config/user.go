@@ -0,0 +1,358 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UserConfigFileName is the name of the per-user config file in the
+// lazytest config directory.
+const UserConfigFileName = "config.yml"
+
+// Options are settings for the whole session rather than one target. The
+// user config sets personal defaults for them and a project config
+// overrides those it sets.
+type Options struct {
+	// Editor is the command that opens files from the results view, with
+	// {file} replaced by the path (appended when missing), e.g.
+	// "code --goto {file}" or "nvim". Empty uses the system's opener.
+	Editor string `yaml:"editor"`
+
+	// Theme is the color theme, one of Themes. Empty or "auto" picks the
+	// dark or light theme to match the terminal background.
+	Theme string `yaml:"theme"`
+
+	// Icons are the status symbols, for terminals whose font lacks the
+	// default ones.
+	Icons Icons `yaml:"icons"`
+}
+
+// Themes lists the built-in color themes.
+var Themes = []string{"auto", "dark", "light", "high-contrast", "colorblind"}
+
+// IconSets lists the built-in icon sets.
+var IconSets = []string{"unicode", "ascii"}
+
+// Icons selects the status symbols: an icon set (one of IconSets; the
+// default is "unicode") with any single symbol replaced. In YAML it is
+// either the set's name or a mapping.
+type Icons struct {
+	Set      string `yaml:"set"`
+	Passed   string `yaml:"passed"`
+	Failed   string `yaml:"failed"`
+	Skipped  string `yaml:"skipped"`
+	Pending  string `yaml:"pending"`
+	Running  string `yaml:"running"`
+	Cursor   string `yaml:"cursor"`
+	Selected string `yaml:"selected"`
+	Duration string `yaml:"duration"`
+}
+
+// UnmarshalYAML accepts an icon set name as well as a mapping.
+func (i *Icons) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		*i = Icons{Set: n.Value}
+		return nil
+	}
+	type plain Icons
+	return n.Decode((*plain)(i))
+}
+
+// Merge returns o with the options it leaves empty taken from defaults.
+func (o Options) Merge(defaults Options) Options {
+	if o.Editor == "" {
+		o.Editor = defaults.Editor
+	}
+	if o.Theme == "" {
+		o.Theme = defaults.Theme
+	}
+	o.Icons = o.Icons.Merge(defaults.Icons)
+	return o
+}
+
+// Merge returns i with the symbols it leaves empty taken from defaults.
+func (i Icons) Merge(defaults Icons) Icons {
+	for _, f := range []struct {
+		v *string
+		d string
+	}{
+		{&i.Set, defaults.Set},
+		{&i.Passed, defaults.Passed},
+		{&i.Failed, defaults.Failed},
+		{&i.Skipped, defaults.Skipped},
+		{&i.Pending, defaults.Pending},
+		{&i.Running, defaults.Running},
+		{&i.Cursor, defaults.Cursor},
+		{&i.Selected, defaults.Selected},
+		{&i.Duration, defaults.Duration},
+	} {
+		if *f.v == "" {
+			*f.v = f.d
+		}
+	}
+	return i
+}
+
+// checkOptions reports unknown themes and icon sets in the options decoded
+// from the config mapping root.
+func checkOptions(root *yaml.Node, o Options, add func(*yaml.Node, string, ...any)) {
+	at := func(n *yaml.Node) *yaml.Node {
+		if n != nil {
+			return n
+		}
+		return root
+	}
+	if o.Theme != "" && !contains(Themes, o.Theme) {
+		add(at(valueNode(root, "theme")), "unknown theme %q (supported: %s)", o.Theme, strings.Join(Themes, ", "))
+	}
+	if o.Icons.Set != "" && !contains(IconSets, o.Icons.Set) {
+		n := valueNode(root, "icons")
+		if n != nil && n.Kind == yaml.MappingNode {
+			n = valueNode(n, "set")
+		}
+		add(at(n), "unknown icon set %q (supported: %s)", o.Icons.Set, strings.Join(IconSets, ", "))
+	}
+}
+
+// checkSearchKeys reports search mode bindings to printable characters,
+// found in the config mapping root. Search mode sends those to the query
+// input, so the binding would make them impossible to type.
+func checkSearchKeys(root *yaml.Node, add func(*yaml.Node, string, ...any)) {
+	search := valueNode(root, "keys")
+	if search != nil {
+		search = valueNode(search, "search")
+	}
+	if search == nil || search.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(search.Content); i += 2 {
+		keys := []*yaml.Node{search.Content[i+1]}
+		if keys[0].Kind == yaml.SequenceNode {
+			keys = keys[0].Content
+		}
+		for _, k := range keys {
+			if k.Kind == yaml.ScalarNode && isPrintableKey(k.Value) {
+				add(k, "search key %q is a printable character and would not reach the search input", k.Value)
+			}
+		}
+	}
+}
+
+// isPrintableKey reports whether the Bubble Tea key name k types a
+// character, like "a", "R" or "space".
+func isPrintableKey(k string) bool {
+	if k == "space" {
+		return true
+	}
+	r, size := utf8.DecodeRuneInString(k)
+	return size == len(k) && r != utf8.RuneError && unicode.IsPrint(r)
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// UserConfig holds a user's preferences for every project: default
+// options, remapped keys and style overrides.
+type UserConfig struct {
+	Options `yaml:",inline"`
+	Keys    Keys   `yaml:"keys"`
+	Styles  Styles `yaml:"styles"`
+}
+
+// Keys remaps key bindings, per mode. Each binding is a key or a list of
+// keys in Bubble Tea notation ("enter", "ctrl+a", "tab", "R"); bindings
+// left out keep their defaults.
+type Keys struct {
+	Search  SearchKeys  `yaml:"search"`
+	Running RunningKeys `yaml:"running"`
+	Results ResultsKeys `yaml:"results"`
+	Sets    SetsKeys    `yaml:"sets"`
+}
+
+// SearchKeys are the bindings of search mode.
+type SearchKeys struct {
+	Run       KeyList `yaml:"run"`
+	Toggle    KeyList `yaml:"toggle"`
+	SelectAll KeyList `yaml:"select_all"`
+	Up        KeyList `yaml:"up"`
+	Down      KeyList `yaml:"down"`
+	Rescan    KeyList `yaml:"rescan"`
+	SaveSet   KeyList `yaml:"save_set"`
+	Sets      KeyList `yaml:"sets"`
+	Quit      KeyList `yaml:"quit"`
+}
+
+// RunningKeys are the bindings of running mode.
+type RunningKeys struct {
+	Cancel KeyList `yaml:"cancel"`
+	Quit   KeyList `yaml:"quit"`
+}
+
+// SetsKeys are the bindings of the test set picker.
+type SetsKeys struct {
+	Up     KeyList `yaml:"up"`
+	Down   KeyList `yaml:"down"`
+	Load   KeyList `yaml:"load"`
+	Save   KeyList `yaml:"save"`
+	Delete KeyList `yaml:"delete"`
+	Close  KeyList `yaml:"close"`
+	Quit   KeyList `yaml:"quit"`
+}
+
+// ResultsKeys are the bindings of results mode.
+type ResultsKeys struct {
+	Up       KeyList `yaml:"up"`
+	Down     KeyList `yaml:"down"`
+	Left     KeyList `yaml:"left"`
+	Right    KeyList `yaml:"right"`
+	Enter    KeyList `yaml:"enter"`
+	Back     KeyList `yaml:"back"`
+	Rerun    KeyList `yaml:"rerun"`
+	RerunAll KeyList `yaml:"rerun_all"`
+	Filter   KeyList `yaml:"filter"`
+	Matrix   KeyList `yaml:"matrix"`
+	Open     KeyList `yaml:"open"`
+	Quit     KeyList `yaml:"quit"`
+}
+
+// KeyList is the keys of one binding. In YAML it is a single key or a list.
+type KeyList []string
+
+// UnmarshalYAML accepts a single key as well as a list of keys.
+func (k *KeyList) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		*k = KeyList{n.Value}
+		return nil
+	}
+	var keys []string
+	if err := n.Decode(&keys); err != nil {
+		return err
+	}
+	*k = keys
+	return nil
+}
+
+// Styles overrides parts of the UI's styles, by element.
+type Styles struct {
+	Title                  Style `yaml:"title"`
+	Border                 Style `yaml:"border"`
+	ActiveBorder           Style `yaml:"active_border"`
+	StatusBar              Style `yaml:"status_bar"`
+	Passed                 Style `yaml:"passed"`
+	Failed                 Style `yaml:"failed"`
+	Skipped                Style `yaml:"skipped"`
+	Running                Style `yaml:"running"`
+	Pending                Style `yaml:"pending"`
+	Item                   Style `yaml:"item"`
+	SelectedItem           Style `yaml:"selected_item"`
+	SelectedMarker         Style `yaml:"selected_marker"`
+	MatchHighlight         Style `yaml:"match_highlight"`
+	SelectedMatchHighlight Style `yaml:"selected_match_highlight"`
+	SuiteName              Style `yaml:"suite_name"`
+	TestName               Style `yaml:"test_name"`
+	DetailTitle            Style `yaml:"detail_title"`
+	DetailBody             Style `yaml:"detail_body"`
+	SearchPrompt           Style `yaml:"search_prompt"`
+	SearchCount            Style `yaml:"search_count"`
+	HelpKey                Style `yaml:"help_key"`
+	HelpDesc               Style `yaml:"help_desc"`
+	Duration               Style `yaml:"duration"`
+}
+
+// Style overrides the attributes of one UI element it sets. Colors are
+// hex ("#7C3AED") or ANSI color numbers ("5"); BorderColor only applies to
+// boxes.
+type Style struct {
+	Foreground  string `yaml:"foreground"`
+	Background  string `yaml:"background"`
+	BorderColor string `yaml:"border_color"`
+	Bold        *bool  `yaml:"bold"`
+	Italic      *bool  `yaml:"italic"`
+	Underline   *bool  `yaml:"underline"`
+	Faint       *bool  `yaml:"faint"`
+}
+
+// UserConfigPath returns the path of the user config:
+// $XDG_CONFIG_HOME/lazytest/config.yml, or ~/.config/lazytest/config.yml.
+func UserConfigPath() (string, error) {
+	base := os.Getenv("XDG_CONFIG_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		base = filepath.Join(home, ".config")
+	}
+	return filepath.Join(base, "lazytest", UserConfigFileName), nil
+}
+
+// LoadUser reads the user config. A missing file is an empty config; a
+// file with problems is an *Error listing them.
+func LoadUser() (UserConfig, error) {
+	path, err := UserConfigPath()
+	if err != nil {
+		return UserConfig{}, nil
+	}
+	u, problems, err := loadUserConfig(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return UserConfig{}, nil
+		}
+		return UserConfig{}, err
+	}
+	if len(problems) > 0 {
+		return UserConfig{}, &Error{Path: path, Problems: problems}
+	}
+	return u, nil
+}
+
+// CheckUser returns every problem in the user config at path.
+func CheckUser(path string) ([]Problem, error) {
+	_, problems, err := loadUserConfig(path)
+	return problems, err
+}
+
+// loadUserConfig strictly decodes the user config at path.
+func loadUserConfig(path string) (UserConfig, []Problem, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return UserConfig{}, nil, err
+	}
+
+	var problems []Problem
+	add := func(n *yaml.Node, format string, args ...any) {
+		problems = append(problems, Problem{Line: n.Line, Column: n.Column, Message: fmt.Sprintf(format, args...)})
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return UserConfig{}, yamlProblems(err), nil
+	}
+	if len(doc.Content) == 0 {
+		return UserConfig{}, nil, nil
+	}
+	root := doc.Content[0]
+	checkKeys(root, reflect.TypeOf(UserConfig{}), add)
+
+	var u UserConfig
+	if err := root.Decode(&u); err != nil {
+		problems = append(problems, yamlProblems(err)...)
+	}
+	checkOptions(root, u.Options, add)
+	checkSearchKeys(root, add)
+	SortProblems(problems)
+	return u, problems, nil
+}
@@ -0,0 +1,173 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadUser(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	writeFile(t, filepath.Join(dir, "lazytest", UserConfigFileName), `editor: "code --goto {file}"
+keys:
+  search:
+    toggle: shift+tab
+    select_all: [ctrl+t, ctrl+a]
+  results:
+    open: e
+styles:
+  passed:
+    foreground: "#00FF00"
+    bold: true
+`)
+
+	u, err := LoadUser()
+	if err != nil {
+		t.Fatalf("LoadUser error: %v", err)
+	}
+	if u.Editor != "code --goto {file}" {
+		t.Errorf("Editor = %q", u.Editor)
+	}
+	if want := (KeyList{"shift+tab"}); !reflect.DeepEqual(u.Keys.Search.Toggle, want) {
+		t.Errorf("search toggle = %v, want %v", u.Keys.Search.Toggle, want)
+	}
+	if want := (KeyList{"ctrl+t", "ctrl+a"}); !reflect.DeepEqual(u.Keys.Search.SelectAll, want) {
+		t.Errorf("search select_all = %v, want %v", u.Keys.Search.SelectAll, want)
+	}
+	if want := (KeyList{"e"}); !reflect.DeepEqual(u.Keys.Results.Open, want) {
+		t.Errorf("results open = %v, want %v", u.Keys.Results.Open, want)
+	}
+	if u.Styles.Passed.Foreground != "#00FF00" || u.Styles.Passed.Bold == nil || !*u.Styles.Passed.Bold {
+		t.Errorf("passed style = %+v", u.Styles.Passed)
+	}
+	if u.Styles.Failed.Bold != nil {
+		t.Errorf("failed style should be untouched, got %+v", u.Styles.Failed)
+	}
+}
+
+func TestLoadUserMissingFile(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	u, err := LoadUser()
+	if err != nil {
+		t.Fatalf("LoadUser error: %v", err)
+	}
+	if !reflect.DeepEqual(u, UserConfig{}) {
+		t.Errorf("LoadUser = %+v, want empty config", u)
+	}
+}
+
+func TestLoadUserRejectsUnknownKeys(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	writeFile(t, filepath.Join(dir, "lazytest", UserConfigFileName), `keys:
+  search:
+    slect_all: ctrl+t
+styles:
+  passed:
+    colour: green
+`)
+
+	_, err := LoadUser()
+	var cfgErr *Error
+	if !errors.As(err, &cfgErr) {
+		t.Fatalf("LoadUser error = %v, want *Error", err)
+	}
+	want := []Problem{
+		{Line: 3, Column: 5, Message: `unknown key "slect_all" (did you mean "select_all"?)`},
+		{Line: 6, Column: 5, Message: `unknown key "colour"`},
+	}
+	if !reflect.DeepEqual(cfgErr.Problems, want) {
+		t.Errorf("Problems = %+v, want %+v", cfgErr.Problems, want)
+	}
+}
+
+func TestCheckUserRejectsPrintableSearchKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), UserConfigFileName)
+	writeFile(t, path, `keys:
+  search:
+    toggle: space
+    select_all: [ctrl+a, a]
+    quit: ctrl+c
+  results:
+    open: e
+`)
+
+	problems, err := CheckUser(path)
+	if err != nil {
+		t.Fatalf("CheckUser error: %v", err)
+	}
+	want := []Problem{
+		{Line: 3, Column: 13, Message: `search key "space" is a printable character and would not reach the search input`},
+		{Line: 4, Column: 26, Message: `search key "a" is a printable character and would not reach the search input`},
+	}
+	if !reflect.DeepEqual(problems, want) {
+		t.Errorf("Problems = %+v, want %+v", problems, want)
+	}
+}
+
+func TestProjectOptionsOverrideUserOptions(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, ConfigFileName)
+	writeFile(t, configPath, `editor: nvim
+targets:
+  - type: phpunit
+`)
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if got := cfg.Options.Merge(Options{Editor: "code"}); got.Editor != "nvim" {
+		t.Errorf("Editor = %q, want the project's nvim", got.Editor)
+	}
+	if got := (Options{}).Merge(Options{Editor: "code"}); got.Editor != "code" {
+		t.Errorf("Editor = %q, want the user's code", got.Editor)
+	}
+}
+
+func TestLoadThemeAndIcons(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, ConfigFileName)
+	writeFile(t, configPath, `theme: colorblind
+icons: ascii
+targets:
+  - type: phpunit
+`)
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	user := Options{Theme: "light", Icons: Icons{Set: "unicode", Failed: "F", Passed: "P"}}
+	got := cfg.Options.Merge(user)
+	want := Options{Theme: "colorblind", Icons: Icons{Set: "ascii", Failed: "F", Passed: "P"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("merged options = %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadRejectsUnknownThemeAndIconSet(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	writeFile(t, filepath.Join(dir, "lazytest", UserConfigFileName), `theme: solarized
+icons:
+  set: emoji
+  failed: "!"
+`)
+
+	_, err := LoadUser()
+	var cfgErr *Error
+	if !errors.As(err, &cfgErr) {
+		t.Fatalf("LoadUser error = %v, want *Error", err)
+	}
+	want := []Problem{
+		{Line: 1, Column: 8, Message: `unknown theme "solarized" (supported: auto, dark, light, high-contrast, colorblind)`},
+		{Line: 3, Column: 8, Message: `unknown icon set "emoji" (supported: unicode, ascii)`},
+	}
+	if !reflect.DeepEqual(cfgErr.Problems, want) {
+		t.Errorf("Problems = %+v, want %+v", cfgErr.Problems, want)
+	}
+}
@@ -0,0 +1,374 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/meijin/lazytest/internal/domain"
+	"github.com/meijin/lazytest/internal/reporter"
+)
+
+// Problem is one thing wrong with a config file. Line and Column are
+// 1-based; Column is 0 when only the line is known. File is set for
+// problems in an included package config. A Warning is a likely mistake
+// that still loads, such as a command without a file placeholder; only
+// Check reports warnings.
+type Problem struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+	Warning bool
+}
+
+// Error reports every problem found in a config file and the package
+// configs it includes.
+type Error struct {
+	Path     string
+	Problems []Problem
+}
+
+// Error formats each problem as "path:line:column: message", one per line.
+func (e *Error) Error() string {
+	lines := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		pos := e.Path
+		if p.File != "" {
+			pos = p.File
+		}
+		if p.Line > 0 {
+			pos += ":" + strconv.Itoa(p.Line)
+			if p.Column > 0 {
+				pos += ":" + strconv.Itoa(p.Column)
+			}
+		}
+		if p.Warning {
+			pos += ": warning"
+		}
+		lines[i] = pos + ": " + p.Message
+	}
+	return strings.Join(lines, "\n")
+}
+
+// errorsOnly returns the problems that aren't warnings.
+func errorsOnly(problems []Problem) []Problem {
+	var errs []Problem
+	for _, p := range problems {
+		if !p.Warning {
+			errs = append(errs, p)
+		}
+	}
+	return errs
+}
+
+// SortProblems orders problems by position: those of the root config first,
+// by line and column, then those of package configs, in the order found.
+func SortProblems(problems []Problem) {
+	sort.SliceStable(problems, func(i, j int) bool {
+		pi, pj := problems[i], problems[j]
+		if pi.File != "" || pj.File != "" {
+			return pi.File == "" && pj.File != ""
+		}
+		if pi.Line != pj.Line {
+			return pi.Line < pj.Line
+		}
+		return pi.Column < pj.Column
+	})
+}
+
+var (
+	// yamlLineRe extracts the line from yaml.v3 syntax and type errors.
+	yamlLineRe = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+	// placeholderRe finds {name} placeholders in commands. "${name}" is a
+	// shell expansion, not a placeholder.
+	placeholderRe = regexp.MustCompile(`(^|[^$])\{([a-z_]+)\}`)
+)
+
+// placeholders lists the command placeholders BuildCommand fills in.
+var placeholders = map[string]bool{
+	"files": true, "file": true, "dirs": true, "names": true, "reporter": true,
+}
+
+// Check reads the config file at configPath and returns every problem in
+// it. Unlike Load it also reports warnings, and test dirs and working dirs
+// that don't exist (relative to the current directory), which Load
+// tolerates.
+func Check(configPath string) ([]Problem, error) {
+	_, problems, err := loadConfig(configPath, true)
+	return problems, err
+}
+
+// parseConfig strictly decodes a config file and checks it, returning the
+// config with defaults applied and every problem found. Targets of a
+// package config (pkg.dir set) are rebased onto the project. checkFS
+// enables the checks that look at the file system.
+func parseConfig(data []byte, pkg pkgConfig, checkFS bool) (Config, []Problem) {
+	var problems []Problem
+	add := func(n *yaml.Node, format string, args ...any) {
+		problems = append(problems, Problem{Line: n.Line, Column: n.Column, Message: fmt.Sprintf(format, args...)})
+	}
+	warn := func(n *yaml.Node, format string, args ...any) {
+		problems = append(problems, Problem{Line: n.Line, Column: n.Column, Message: fmt.Sprintf(format, args...), Warning: true})
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return Config{}, yamlProblems(err)
+	}
+	if len(doc.Content) == 0 {
+		return Config{}, nil
+	}
+	root := doc.Content[0]
+
+	checkKeys(root, reflect.TypeOf(Config{}), add)
+
+	var cfg Config
+	if err := root.Decode(&cfg); err != nil {
+		problems = append(problems, yamlProblems(err)...)
+	}
+	checkOptions(root, cfg.Options, add)
+
+	var targetNodes []*yaml.Node
+	if n := valueNode(root, "targets"); n != nil && n.Kind == yaml.SequenceNode {
+		targetNodes = n.Content
+	}
+	firstLine := make(map[string]int)
+	for i := range cfg.Targets {
+		t := &cfg.Targets[i]
+		node := root
+		if i < len(targetNodes) {
+			node = targetNodes[i]
+		}
+		if t.Type != "" && !isType(t.Type) {
+			add(nodeOr(node, "type"), "unknown type %q (supported: %s)", t.Type, strings.Join(Types, ", "))
+		}
+		t.applyDefaults()
+		if pkg.dir != "" {
+			pkg.rebase(t)
+		}
+
+		if line, dup := firstLine[t.Name]; dup {
+			add(nodeOr(node, "name"), "duplicate target name %q (first defined on line %d)", t.Name, line)
+		} else {
+			firstLine[t.Name] = nodeOr(node, "name").Line
+		}
+		if strings.ContainsAny(t.Name, "[]") {
+			add(nodeOr(node, "name"), "target name %q must not contain brackets, which mark matrix variants", t.Name)
+		}
+
+		checkCommand(*t, nodeOr(node, "command"), add, warn)
+		checkMatrix(*t, node, add, warn)
+		checkPathMap(*t, node, add)
+
+		dirs := valueNode(node, "test_dirs")
+		for j, d := range t.TestDirs {
+			n := nodeOr(node, "test_dirs")
+			if dirs != nil && dirs.Kind == yaml.SequenceNode && j < len(dirs.Content) {
+				n = dirs.Content[j]
+			}
+			if t.WorkingDir != "" && !underDir(d, t.WorkingDir) {
+				warn(n, "test_dirs: %q is not under working_dir %q, so its paths can't be made relative to it", d, t.WorkingDir)
+			}
+			if !checkFS {
+				continue
+			}
+			if info, err := os.Stat(d); err != nil || !info.IsDir() {
+				if dirs == nil {
+					add(n, "default test dir %q does not exist; set test_dirs", d)
+				} else {
+					add(n, "test_dirs: %q does not exist", d)
+				}
+			}
+		}
+		if checkFS && t.WorkingDir != "" {
+			if info, err := os.Stat(t.WorkingDir); err != nil || !info.IsDir() {
+				add(nodeOr(node, "working_dir"), "working_dir: %q does not exist", t.WorkingDir)
+			}
+		}
+	}
+
+	checkSets(root, cfg.Sets, add)
+
+	SortProblems(problems)
+	return cfg, problems
+}
+
+// checkSets reports sets without a name, duplicate names and sets that
+// select nothing.
+func checkSets(root *yaml.Node, sets []domain.TestSet, add func(*yaml.Node, string, ...any)) {
+	var setNodes []*yaml.Node
+	if n := valueNode(root, "sets"); n != nil && n.Kind == yaml.SequenceNode {
+		setNodes = n.Content
+	}
+	firstLine := make(map[string]int)
+	for i, s := range sets {
+		node := root
+		if i < len(setNodes) {
+			node = setNodes[i]
+		}
+		switch line, dup := firstLine[s.Name]; {
+		case s.Name == "":
+			add(node, "set has no name")
+		case dup:
+			add(nodeOr(node, "name"), "duplicate set name %q (first defined on line %d)", s.Name, line)
+		default:
+			firstLine[s.Name] = nodeOr(node, "name").Line
+		}
+		if strings.TrimSpace(s.Query) == "" && len(s.Files) == 0 {
+			add(node, "set %q has neither a query nor files", s.Name)
+		}
+	}
+}
+
+// checkCommand reports unknown placeholders and {reporter} for types
+// without a built-in reporter, and warns about a missing file placeholder.
+func checkCommand(t Target, n *yaml.Node, add, warn func(*yaml.Node, string, ...any)) {
+	for _, m := range placeholderRe.FindAllStringSubmatch(t.Command, -1) {
+		if !placeholders[m[2]] {
+			add(n, "command: unknown placeholder {%s} (supported: {files}, {file}, {dirs}, {names}, {reporter})", m[2])
+		}
+	}
+	if !strings.Contains(t.Command, "{files}") && !strings.Contains(t.Command, "{file}") &&
+		!strings.Contains(t.Command, "{dirs}") && !strings.Contains(t.Command, "{names}") {
+		warn(n, "command: no {files}, {file}, {dirs} or {names} placeholder, so the selected tests would be ignored")
+	}
+	if strings.Contains(t.Command, "{reporter}") && !reporter.Has(t.Type) {
+		add(n, "command: {reporter} is only available for vitest, jest, rspec and pytest targets, not %s", t.Type)
+	}
+}
+
+// checkKeys reports mapping keys of n that don't correspond to a yaml field
+// of t, recursing into nested structs and slices.
+func checkKeys(n *yaml.Node, t reflect.Type, add func(*yaml.Node, string, ...any)) {
+	switch {
+	case t.Kind() == reflect.Struct && n.Kind == yaml.MappingNode:
+		fields := make(map[string]reflect.Type)
+		var names []string
+		yamlFields(t, func(name string, ft reflect.Type) {
+			fields[name] = ft
+			names = append(names, name)
+		})
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			ft, ok := fields[key.Value]
+			switch {
+			case ok:
+				checkKeys(value, ft, add)
+			case key.Value == "<<":
+				// merge key; the merged mapping is checked where it's defined
+			case suggest(key.Value, names) != "":
+				add(key, "unknown key %q (did you mean %q?)", key.Value, suggest(key.Value, names))
+			default:
+				add(key, "unknown key %q", key.Value)
+			}
+		}
+	case t.Kind() == reflect.Slice && n.Kind == yaml.SequenceNode:
+		for _, item := range n.Content {
+			checkKeys(item, t.Elem(), add)
+		}
+	}
+}
+
+// yamlFields calls fn with the yaml name and type of each field of the
+// struct type t, including those of inlined structs.
+func yamlFields(t reflect.Type, fn func(string, reflect.Type)) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		switch {
+		case opts == "inline" && f.Type.Kind() == reflect.Struct:
+			yamlFields(f.Type, fn)
+		case name != "" && name != "-":
+			fn(name, f.Type)
+		}
+	}
+}
+
+// suggest returns the known key closest to key, if it's a likely typo.
+func suggest(key string, known []string) string {
+	best, bestDist := "", 3
+	for _, k := range known {
+		if d := editDistance(key, k); d < bestDist {
+			best, bestDist = k, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// valueNode returns the value for key in the mapping n, or nil.
+func valueNode(n *yaml.Node, key string) *yaml.Node {
+	if n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// nodeOr returns the value for key in the mapping node, or node itself when
+// key is missing, so problems with the key point at the closest position.
+func nodeOr(node *yaml.Node, key string) *yaml.Node {
+	if n := valueNode(node, key); n != nil {
+		return n
+	}
+	return node
+}
+
+// yamlProblems converts yaml.v3 syntax and type errors into problems.
+func yamlProblems(err error) []Problem {
+	var msgs []string
+	if te, ok := err.(*yaml.TypeError); ok {
+		msgs = te.Errors
+	} else {
+		msgs = []string{err.Error()}
+	}
+
+	problems := make([]Problem, len(msgs))
+	for i, msg := range msgs {
+		problems[i] = Problem{Message: strings.TrimPrefix(msg, "yaml: ")}
+		if m := yamlLineRe.FindStringSubmatch(msg); m != nil {
+			problems[i].Line, _ = strconv.Atoi(m[1])
+			problems[i].Message = m[2]
+		}
+	}
+	return problems
+}
+
+// underDir reports whether p is dir or inside it.
+func underDir(p, dir string) bool {
+	p, dir = path.Clean(p), path.Clean(dir)
+	return dir == "." || p == dir || strings.HasPrefix(p, dir+"/")
+}
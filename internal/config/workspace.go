@@ -0,0 +1,306 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/meijin/lazytest/internal/glob"
+	"gopkg.in/yaml.v3"
+)
+
+// maxWorkspaceDepth bounds the walk used to expand "**" workspace globs.
+const maxWorkspaceDepth = 8
+
+// packageJSON is the subset of package.json used for detection.
+type packageJSON struct {
+	Workspaces      json.RawMessage   `json:"workspaces"`
+	Dependencies    map[string]string `json:"dependencies"`
+	DevDependencies map[string]string `json:"devDependencies"`
+}
+
+// composerJSON is the subset of composer.json used for detection.
+type composerJSON struct {
+	Require      map[string]string `json:"require"`
+	RequireDev   map[string]string `json:"require-dev"`
+	Repositories []struct {
+		Type string `json:"type"`
+		URL  string `json:"url"`
+	} `json:"repositories"`
+}
+
+// workspace describes the packages declared by a monorepo's manifests.
+type workspace struct {
+	packages []string // package dirs relative to root, slash-separated
+	pnpm     bool     // packages are managed by pnpm
+}
+
+// readWorkspace collects package directories from npm/yarn "workspaces",
+// pnpm-workspace.yaml, nx.json/workspace.json project lists and Composer path
+// repositories. It returns false if root declares no workspace, or none of
+// its packages exist inside root.
+// (turbo.json has no project list; Turborepo relies on the package manager's
+// workspaces, which are read here.)
+func readWorkspace(root string) (workspace, bool) {
+	var ws workspace
+	var patterns []string
+	found := false
+
+	if pkg, ok := readPackageJSON(root); ok && len(pkg.Workspaces) > 0 {
+		var list []string
+		var obj struct {
+			Packages []string `json:"packages"`
+		}
+		if json.Unmarshal(pkg.Workspaces, &list) == nil {
+			patterns = append(patterns, list...)
+			found = true
+		} else if json.Unmarshal(pkg.Workspaces, &obj) == nil {
+			patterns = append(patterns, obj.Packages...)
+			found = true
+		}
+	}
+
+	if data, err := os.ReadFile(filepath.Join(root, "pnpm-workspace.yaml")); err == nil {
+		var pnpm struct {
+			Packages []string `yaml:"packages"`
+		}
+		if yaml.Unmarshal(data, &pnpm) == nil {
+			patterns = append(patterns, pnpm.Packages...)
+			ws.pnpm = true
+			found = true
+		}
+	}
+
+	var dirs []string
+	for _, name := range []string{"workspace.json", "nx.json"} {
+		data, err := os.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			continue
+		}
+		var nx struct {
+			Projects map[string]json.RawMessage `json:"projects"`
+		}
+		if json.Unmarshal(data, &nx) != nil {
+			continue
+		}
+		for _, raw := range nx.Projects {
+			var path string
+			var obj struct {
+				Root string `json:"root"`
+			}
+			if json.Unmarshal(raw, &path) == nil && path != "" {
+				dirs = append(dirs, path)
+			} else if json.Unmarshal(raw, &obj) == nil && obj.Root != "" {
+				dirs = append(dirs, obj.Root)
+			}
+		}
+		found = found || len(nx.Projects) > 0
+	}
+
+	if composer, ok := readComposerJSON(root); ok {
+		for _, repo := range composer.Repositories {
+			if repo.Type == "path" && repo.URL != "" {
+				patterns = append(patterns, repo.URL)
+				found = true
+			}
+		}
+	}
+
+	if !found {
+		return ws, false
+	}
+
+	seen := make(map[string]bool)
+	for _, d := range append(expandWorkspaceGlobs(root, patterns), dirs...) {
+		d = strings.Trim(filepath.ToSlash(filepath.Clean(d)), "/")
+		if d == "" || d == "." || strings.HasPrefix(d, "..") || seen[d] {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(root, d)); err != nil || !info.IsDir() {
+			continue
+		}
+		seen[d] = true
+		ws.packages = append(ws.packages, d)
+	}
+	sort.Strings(ws.packages)
+	return ws, len(ws.packages) > 0
+}
+
+// covers reports whether rel is a workspace package or inside one.
+func (ws workspace) covers(rel string) bool {
+	for _, p := range ws.packages {
+		if rel == p || strings.HasPrefix(rel, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// expandWorkspaceGlobs resolves workspace patterns (which may use "**" and
+// "!" exclusions) to directories relative to root.
+func expandWorkspaceGlobs(root string, patterns []string) []string {
+	var include, exclude []string
+	for _, p := range patterns {
+		p = strings.TrimPrefix(filepath.ToSlash(p), "./")
+		p = strings.TrimSuffix(p, "/")
+		if strings.HasPrefix(p, "!") {
+			exclude = append(exclude, strings.TrimPrefix(p[1:], "./"))
+		} else if p != "" {
+			include = append(include, p)
+		}
+	}
+	if len(include) == 0 {
+		return nil
+	}
+
+	var dirs []string
+	walkWorkspaceDirs(root, "", 0, func(rel string) {
+		matched := false
+		for _, p := range include {
+			if glob.Match(p, rel) {
+				matched = true
+				break
+			}
+		}
+		for _, p := range exclude {
+			if glob.Match(p, rel) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			dirs = append(dirs, rel)
+		}
+	})
+	return dirs
+}
+
+// walkWorkspaceDirs calls fn with the slash-separated path of every
+// directory below root, skipping dependency and hidden directories.
+func walkWorkspaceDirs(root, rel string, depth int, fn func(rel string)) {
+	if depth >= maxWorkspaceDepth {
+		return
+	}
+	entries, err := os.ReadDir(filepath.Join(root, filepath.FromSlash(rel)))
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || skipDirs[name] || strings.HasPrefix(name, ".") {
+			continue
+		}
+		child := name
+		if rel != "" {
+			child = rel + "/" + name
+		}
+		fn(child)
+		walkWorkspaceDirs(root, child, depth+1, fn)
+	}
+}
+
+func readPackageJSON(dir string) (packageJSON, bool) {
+	var pkg packageJSON
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil || json.Unmarshal(data, &pkg) != nil {
+		return pkg, false
+	}
+	return pkg, true
+}
+
+func readComposerJSON(dir string) (composerJSON, bool) {
+	var c composerJSON
+	data, err := os.ReadFile(filepath.Join(dir, "composer.json"))
+	if err != nil || json.Unmarshal(data, &c) != nil {
+		return c, false
+	}
+	return c, true
+}
+
+// hasDependency reports whether name is a (dev) dependency of the package.
+func (p packageJSON) hasDependency(name string) bool {
+	_, dev := p.DevDependencies[name]
+	_, prod := p.Dependencies[name]
+	return dev || prod
+}
+
+// hasDependency reports whether name is required (dev or not) by the package.
+func (c composerJSON) hasDependency(name string) bool {
+	_, dev := c.RequireDev[name]
+	_, prod := c.Require[name]
+	return dev || prod
+}
+
+// detectPackageTargets detects the test frameworks of the package at rel
+// (relative to root, "" for root itself) from its config files. With useDeps
+// it also detects frameworks declared only as dependencies. Packages that
+// depend on Pest get a "pest" target instead of "phpunit". Other frameworks
+// are detected from their marker files (see markerFrameworks).
+func detectPackageTargets(root, rel string, ws workspace, useDeps bool) []Target {
+	dir := filepath.Join(root, filepath.FromSlash(rel))
+	var targets []Target
+
+	composer, hasComposer := readComposerJSON(dir)
+	isPest := hasComposer && composer.hasDependency("pestphp/pest")
+	if t, found := detectPHPUnitTarget(dir, rel); found {
+		if isPest {
+			t.Name, t.Type, t.Command = "pest", "pest", ""
+			t.applyDefaults()
+		}
+		targets = append(targets, t)
+	} else if useDeps && isPest {
+		targets = append(targets, phpTarget("pest", nil, rel))
+	} else if useDeps && hasComposer && composer.hasDependency("phpunit/phpunit") {
+		targets = append(targets, phpTarget("phpunit", nil, rel))
+	}
+
+	pkg, hasPackage := readPackageJSON(dir)
+	for _, fw := range []string{"vitest", "jest"} {
+		var t Target
+		var found bool
+		if fw == "vitest" {
+			t, found = detectVitestTarget(dir, rel)
+		} else {
+			t, found = detectJestTarget(dir, rel)
+		}
+		if !found && useDeps && hasPackage && pkg.hasDependency(fw) {
+			t, found = jsTarget(fw, dir, rel), true
+		}
+		if !found {
+			continue
+		}
+		if ws.pnpm {
+			t.Command = "pnpm exec " + strings.TrimPrefix(t.Command, "npx ")
+		}
+		targets = append(targets, t)
+	}
+	return append(targets, detectMarkerTargets(dir, rel)...)
+}
+
+// phpTarget builds a PHP target rooted at the package dir rel. Empty dirs
+// fall back to the package's tests/ directory.
+func phpTarget(name string, dirs []string, rel string) Target {
+	if len(dirs) == 0 {
+		dirs = []string{workingDirFor(rel) + "tests/"}
+	}
+	t := Target{Name: name, Type: name, TestDirs: dirs, WorkingDir: workingDirFor(rel)}
+	t.applyDefaults()
+	return t
+}
+
+// jsTarget builds a Vitest or Jest target for a package without a config
+// file. Tests are looked up in src/ when it exists, else the whole package.
+func jsTarget(name, dir, rel string) Target {
+	testDir := workingDirFor(rel)
+	if info, err := os.Stat(filepath.Join(dir, "src")); err == nil && info.IsDir() {
+		testDir += "src/"
+	}
+	if testDir == "" {
+		testDir = "./"
+	}
+	t := Target{Name: name, Type: name, TestDirs: []string{testDir}, WorkingDir: workingDirFor(rel)}
+	t.applyDefaults()
+	return t
+}
@@ -0,0 +1,151 @@
+package discovery
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/meijin/lazytest/internal/glob"
+)
+
+// ignoreFileNames are read from every scanned directory, in this order.
+var ignoreFileNames = []string{".gitignore", ".ignore"}
+
+// alwaysSkipDirs are never descended into, ignore files or not.
+var alwaysSkipDirs = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+	"vendor":       true,
+}
+
+// ignoreRule is a single gitignore-style pattern.
+type ignoreRule struct {
+	base     string // slash path of the directory the rule is relative to ("" for the scan root)
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool // pattern contains a slash, so it matches the path relative to base
+}
+
+// ignoreMatcher evaluates gitignore-style rules. Rules are kept in load
+// order and the last matching rule wins, as in git.
+type ignoreMatcher struct {
+	rules  []ignoreRule
+	loaded map[string]bool
+}
+
+func newIgnoreMatcher() *ignoreMatcher {
+	return &ignoreMatcher{loaded: make(map[string]bool)}
+}
+
+// addPatterns appends gitignore-syntax lines relative to base.
+func (m *ignoreMatcher) addPatterns(base string, lines []string) {
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		r := ignoreRule{base: base}
+		if strings.HasPrefix(line, "!") {
+			r.negate = true
+			line = line[1:]
+		}
+		line = strings.TrimPrefix(line, `\`)
+		if strings.HasSuffix(line, "/") {
+			r.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			r.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		r.pattern = line
+		m.rules = append(m.rules, r)
+	}
+}
+
+// loadDir reads the ignore files in dir (once). base is dir's slash path as
+// it appears in walked paths.
+func (m *ignoreMatcher) loadDir(dir, base string) {
+	if m.loaded[base] {
+		return
+	}
+	m.loaded[base] = true
+
+	for _, name := range ignoreFileNames {
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		var lines []string
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		f.Close()
+		m.addPatterns(base, lines)
+	}
+}
+
+// match reports whether the slash path p is ignored.
+func (m *ignoreMatcher) match(p string, isDir bool) bool {
+	ignored := false
+	for _, r := range m.rules {
+		rel := p
+		if r.base != "" {
+			if !strings.HasPrefix(p, r.base+"/") {
+				continue
+			}
+			rel = p[len(r.base)+1:]
+		}
+		if r.dirOnly && !isDir {
+			continue
+		}
+
+		var ok bool
+		if r.anchored {
+			ok = glob.Match(r.pattern, rel)
+		} else {
+			ok = glob.Match(r.pattern, path.Base(rel))
+		}
+		if ok {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// slashBase converts a directory path into the form used as a rule base.
+func slashBase(dir string) string {
+	base := filepath.ToSlash(filepath.Clean(dir))
+	if base == "." {
+		return ""
+	}
+	return base
+}
+
+// loadAncestors loads ignore files from the current directory down to (but
+// not including) dir, so that project-level .gitignore files apply to
+// nested test directories. Absolute or parent-relative dirs load nothing.
+func (m *ignoreMatcher) loadAncestors(dir string) {
+	base := slashBase(dir)
+	if filepath.IsAbs(dir) || base == ".." || strings.HasPrefix(base, "../") {
+		return
+	}
+
+	m.loadDir(".", "")
+	if base == "" {
+		return
+	}
+	parts := strings.Split(base, "/")
+	for i := 1; i < len(parts); i++ {
+		anc := strings.Join(parts[:i], "/")
+		m.loadDir(filepath.FromSlash(anc), anc)
+	}
+}
@@ -0,0 +1,117 @@
+package discovery
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/meijin/lazytest/internal/config"
+	"github.com/meijin/lazytest/internal/domain"
+	"github.com/meijin/lazytest/internal/state"
+)
+
+const (
+	indexFileName = "index.json"
+	indexVersion  = 2
+)
+
+// fileIndex is the persisted result of a full scan.
+type fileIndex struct {
+	Version int              `json:"version"`
+	Key     string           `json:"key"`  // fingerprint of the targets' scan settings
+	Dirs    map[string]int64 `json:"dirs"` // walked directory → mtime (UnixNano)
+	Files   []indexedFile    `json:"files"`
+}
+
+type indexedFile struct {
+	Path   string `json:"path"`
+	Target string `json:"target"`
+	Suite  string `json:"suite,omitempty"`
+}
+
+// indexKey fingerprints the settings that affect which files a scan finds.
+func indexKey(targets []config.Target) string {
+	type scanSettings struct {
+		Name        string
+		TestDirs    []string
+		FilePattern string
+		Exclude     []string
+		Files       []string
+		Suites      []string
+	}
+	settings := make([]scanSettings, len(targets))
+	for i, t := range targets {
+		settings[i] = scanSettings{t.Name, t.TestDirs, t.FilePattern, t.Exclude, t.Files, t.Suites}
+	}
+	data, _ := json.Marshal(settings)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// LoadIndex returns the files recorded by the last ScanAndIndex in stateDir,
+// provided the targets are unchanged and no walked directory has been
+// modified since. The second result is false if the index is missing or stale.
+func LoadIndex(stateDir string, targets []config.Target) ([]domain.TestFile, bool) {
+	if stateDir == "" {
+		return nil, false
+	}
+	data, err := os.ReadFile(filepath.Join(stateDir, indexFileName))
+	if err != nil {
+		return nil, false
+	}
+
+	var idx fileIndex
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return nil, false
+	}
+	if idx.Version != indexVersion || idx.Key != indexKey(targets) {
+		return nil, false
+	}
+
+	for dir, mtime := range idx.Dirs {
+		info, err := os.Stat(dir)
+		switch {
+		case err != nil && mtime != 0:
+			return nil, false // directory was removed
+		case err == nil && info.ModTime().UnixNano() != mtime:
+			return nil, false // entries were added, removed or renamed
+		}
+	}
+
+	types := make(map[string]string, len(targets))
+	for _, t := range targets {
+		types[t.Name] = t.Framework()
+	}
+	files := make([]domain.TestFile, len(idx.Files))
+	for i, f := range idx.Files {
+		files[i] = domain.TestFile{Path: f.Path, TargetName: f.Target, TargetType: types[f.Target], Suite: f.Suite}
+	}
+	return files, true
+}
+
+// ScanAndIndex scans all targets and, when stateDir is set, persists the
+// result so the next LoadIndex can skip the walk.
+func ScanAndIndex(stateDir string, targets []config.Target) ([]domain.TestFile, error) {
+	files, dirs, err := scanAllTargets(targets, stateDir != "")
+	if err != nil || stateDir == "" {
+		return files, err
+	}
+
+	idx := fileIndex{
+		Version: indexVersion,
+		Key:     indexKey(targets),
+		Dirs:    dirs,
+		Files:   make([]indexedFile, len(files)),
+	}
+	for i, f := range files {
+		idx.Files[i] = indexedFile{Path: f.Path, Target: f.TargetName, Suite: f.Suite}
+	}
+
+	// The index is only a cache; failing to write it is not an error.
+	if data, err := json.Marshal(idx); err == nil {
+		state.WriteFileAtomic(filepath.Join(stateDir, indexFileName), data)
+	}
+	return files, nil
+}
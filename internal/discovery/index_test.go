@@ -0,0 +1,77 @@
+package discovery
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/meijin/lazytest/internal/config"
+)
+
+func TestLoadIndexRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	stateDir := t.TempDir()
+	testDir := filepath.Join(dir, "tests")
+	os.MkdirAll(testDir, 0755)
+	os.WriteFile(filepath.Join(testDir, "FooTest.php"), []byte(""), 0644)
+
+	targets := []config.Target{{Name: "phpunit", TestDirs: []string{testDir}, FilePattern: "*Test.php"}}
+
+	scanned, err := ScanAndIndex(stateDir, targets)
+	if err != nil {
+		t.Fatalf("ScanAndIndex error: %v", err)
+	}
+
+	cached, ok := LoadIndex(stateDir, targets)
+	if !ok {
+		t.Fatal("expected index to be valid")
+	}
+	if len(cached) != len(scanned) || cached[0] != scanned[0] {
+		t.Errorf("cached = %v, want %v", cached, scanned)
+	}
+}
+
+func TestLoadIndexInvalidatedByNewFile(t *testing.T) {
+	dir := t.TempDir()
+	stateDir := t.TempDir()
+	testDir := filepath.Join(dir, "tests")
+	os.MkdirAll(testDir, 0755)
+	os.WriteFile(filepath.Join(testDir, "FooTest.php"), []byte(""), 0644)
+
+	targets := []config.Target{{Name: "phpunit", TestDirs: []string{testDir}, FilePattern: "*Test.php"}}
+	if _, err := ScanAndIndex(stateDir, targets); err != nil {
+		t.Fatalf("ScanAndIndex error: %v", err)
+	}
+
+	newFile := filepath.Join(testDir, "BarTest.php")
+	os.WriteFile(newFile, []byte(""), 0644)
+	// Make sure the directory mtime differs even on coarse-grained filesystems.
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(testDir, future, future)
+
+	if _, ok := LoadIndex(stateDir, targets); ok {
+		t.Error("expected index to be stale after adding a file")
+	}
+}
+
+func TestLoadIndexInvalidatedByTargetChange(t *testing.T) {
+	dir := t.TempDir()
+	stateDir := t.TempDir()
+
+	targets := []config.Target{{Name: "phpunit", TestDirs: []string{dir}, FilePattern: "*Test.php"}}
+	if _, err := ScanAndIndex(stateDir, targets); err != nil {
+		t.Fatalf("ScanAndIndex error: %v", err)
+	}
+
+	targets[0].FilePattern = "*Spec.php"
+	if _, ok := LoadIndex(stateDir, targets); ok {
+		t.Error("expected index to be stale after changing file_pattern")
+	}
+}
+
+func TestLoadIndexWithoutStateDir(t *testing.T) {
+	if _, ok := LoadIndex("", nil); ok {
+		t.Error("expected no index without a state dir")
+	}
+}
@@ -2,38 +2,109 @@ package discovery
 
 import (
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/meijin/lazytest/internal/config"
 	"github.com/meijin/lazytest/internal/domain"
+	"github.com/meijin/lazytest/internal/glob"
 )
 
 // ScanFiles scans the given directories for files matching the pattern.
 // Pattern can be comma-separated (e.g. "*.test.ts,*.test.tsx") for OR matching.
 // Returns relative paths sorted alphabetically.
 func ScanFiles(dirs []string, pattern string) ([]string, error) {
+	return scanFiles(dirs, pattern, nil, nil)
+}
+
+// ScanTargetFiles scans a target's test dirs, honoring its exclude patterns,
+// and adds its explicitly listed files that exist.
+func ScanTargetFiles(target config.Target) ([]string, error) {
+	return scanTarget(target, nil)
+}
+
+func scanTarget(target config.Target, dirMtimes map[string]int64) ([]string, error) {
+	paths, err := scanFiles(target.TestDirs, target.FilePattern, target.Exclude, dirMtimes)
+	if err != nil || len(target.Files) == 0 {
+		return paths, err
+	}
+
+	seen := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		seen[p] = true
+	}
+	for _, f := range target.Files {
+		p := filepath.ToSlash(filepath.Clean(f))
+		if seen[p] {
+			continue
+		}
+		if info, err := os.Stat(f); err == nil && !info.IsDir() {
+			seen[p] = true
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// scanFiles walks dirs collecting files that match pattern. Patterns without
+// a slash match the file name; patterns with a slash (which may use "**")
+// match the path relative to the scanned dir or to the project root.
+// .gitignore/.ignore files are honored, and exclude holds additional
+// gitignore-style patterns relative to the project root. If dirMtimes is
+// non-nil, the modification time of every walked directory is recorded in it
+// (0 for scan roots that don't exist) so the result can be validated later.
+func scanFiles(dirs []string, pattern string, exclude []string, dirMtimes map[string]int64) ([]string, error) {
 	patterns := splitPatterns(pattern)
+	excludes := newIgnoreMatcher()
+	excludes.addPatterns("", exclude)
+	ignores := newIgnoreMatcher()
 	seen := make(map[string]bool)
 	var files []string
 
 	for _, dir := range dirs {
-		err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		root := filepath.Clean(dir)
+		rootSlash := filepath.ToSlash(root)
+		ignores.loadAncestors(root)
+		if dirMtimes != nil {
+			dirMtimes[root] = 0
+		}
+
+		err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
 			if err != nil {
 				return nil // skip inaccessible files/dirs
 			}
+
+			// Normalize to forward slashes for consistency
+			rel := filepath.ToSlash(path)
+			relToRoot := rel
+			if rootSlash != "." {
+				relToRoot = strings.TrimPrefix(strings.TrimPrefix(rel, rootSlash), "/")
+			}
+
 			if d.IsDir() {
+				if path != root && (alwaysSkipDirs[d.Name()] || ignores.match(rel, true) ||
+					excludes.match(rel, true) || excludes.match(relToRoot, true)) {
+					return filepath.SkipDir
+				}
+				if dirMtimes != nil {
+					if info, err := d.Info(); err == nil {
+						dirMtimes[path] = info.ModTime().UnixNano()
+					}
+				}
+				ignores.loadDir(path, slashBase(path))
 				return nil
 			}
 
-			if matchesAny(d.Name(), patterns) {
-				// Normalize to forward slashes for consistency
-				rel := filepath.ToSlash(path)
-				if !seen[rel] {
-					seen[rel] = true
-					files = append(files, rel)
-				}
+			if ignores.match(rel, false) || excludes.match(rel, false) || excludes.match(relToRoot, false) {
+				return nil
+			}
+			if matchesAny(rel, relToRoot, patterns) && !seen[rel] {
+				seen[rel] = true
+				files = append(files, rel)
 			}
 			return nil
 		})
@@ -51,21 +122,65 @@ func ScanFiles(dirs []string, pattern string) ([]string, error) {
 }
 
 // ScanAllTargets scans files for all targets and returns them as TestFile slice.
-// Results are sorted by target name then path.
+// Targets are scanned concurrently. Results are sorted by target name then path.
 func ScanAllTargets(targets []config.Target) ([]domain.TestFile, error) {
-	var allFiles []domain.TestFile
+	files, _, err := scanAllTargets(targets, false)
+	return files, err
+}
 
-	for _, target := range targets {
-		paths, err := ScanFiles(target.TestDirs, target.FilePattern)
-		if err != nil {
-			return nil, err
+// scanAllTargets scans every target in its own goroutine. When trackDirs is
+// set it also returns the mtimes of all walked directories.
+func scanAllTargets(targets []config.Target, trackDirs bool) ([]domain.TestFile, map[string]int64, error) {
+	type targetScan struct {
+		paths []string
+		dirs  map[string]int64
+		err   error
+	}
+	scans := make([]targetScan, len(targets))
+
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target config.Target) {
+			defer wg.Done()
+			var dirs map[string]int64
+			if trackDirs {
+				dirs = make(map[string]int64)
+			}
+			paths, err := scanTarget(target, dirs)
+			scans[i] = targetScan{paths: paths, dirs: dirs, err: err}
+		}(i, target)
+	}
+	wg.Wait()
+
+	var allFiles []domain.TestFile
+	var allDirs map[string]int64
+	if trackDirs {
+		allDirs = make(map[string]int64)
+	}
+	for i, scan := range scans {
+		if scan.err != nil {
+			return nil, nil, scan.err
 		}
-		for _, p := range paths {
+		typ := targets[i].Framework()
+		for _, p := range scan.paths {
 			allFiles = append(allFiles, domain.TestFile{
 				Path:       p,
-				TargetName: target.Name,
+				TargetName: targets[i].Name,
+				TargetType: typ,
 			})
 		}
+		for _, suite := range targets[i].Suites {
+			allFiles = append(allFiles, domain.TestFile{
+				Path:       domain.SuitePathPrefix + suite,
+				TargetName: targets[i].Name,
+				TargetType: typ,
+				Suite:      suite,
+			})
+		}
+		for d, mtime := range scan.dirs {
+			allDirs[d] = mtime
+		}
 	}
 
 	// Sort by target name, then by path
@@ -76,7 +191,7 @@ func ScanAllTargets(targets []config.Target) ([]domain.TestFile, error) {
 		return allFiles[i].Path < allFiles[j].Path
 	})
 
-	return allFiles, nil
+	return allFiles, allDirs, nil
 }
 
 // splitPatterns splits a comma-separated pattern string into individual patterns.
@@ -92,11 +207,18 @@ func splitPatterns(pattern string) []string {
 	return patterns
 }
 
-// matchesAny returns true if name matches any of the patterns.
-func matchesAny(name string, patterns []string) bool {
-	for _, p := range patterns {
-		matched, err := filepath.Match(p, name)
-		if err == nil && matched {
+// matchesAny returns true if the file matches any of the patterns. p is the
+// file's slash path and relToRoot the same path relative to the scanned dir.
+func matchesAny(p, relToRoot string, patterns []string) bool {
+	for _, pat := range patterns {
+		if !strings.Contains(pat, "/") {
+			if matched, err := path.Match(pat, path.Base(p)); err == nil && matched {
+				return true
+			}
+			continue
+		}
+		pat = strings.TrimPrefix(pat, "./")
+		if glob.Match(pat, relToRoot) || glob.Match(pat, p) {
 			return true
 		}
 	}
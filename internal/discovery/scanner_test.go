@@ -162,6 +162,9 @@ func TestScanAllTargets(t *testing.T) {
 	if files[2].TargetName != "vitest" {
 		t.Errorf("files[2].TargetName = %q, want vitest", files[2].TargetName)
 	}
+	if files[0].TargetType != "phpunit" || files[2].TargetType != "vitest" {
+		t.Errorf("TargetTypes = %q, %q, want phpunit, vitest", files[0].TargetType, files[2].TargetType)
+	}
 }
 
 func TestScanAllTargetsEmpty(t *testing.T) {
@@ -173,3 +176,142 @@ func TestScanAllTargetsEmpty(t *testing.T) {
 		t.Errorf("got %d files, want 0", len(files))
 	}
 }
+
+func TestScanHonorsGitignore(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "src"), 0755)
+	os.MkdirAll(filepath.Join(dir, "dist", "src"), 0755)
+	os.MkdirAll(filepath.Join(dir, "node_modules", "pkg"), 0755)
+	os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("dist/\n*.gen.test.ts\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "src", "app.test.ts"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(dir, "src", "api.gen.test.ts"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(dir, "dist", "src", "app.test.ts"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(dir, "node_modules", "pkg", "index.test.ts"), []byte(""), 0644)
+
+	files, err := ScanFiles([]string{dir}, "*.test.ts")
+	if err != nil {
+		t.Fatalf("ScanFiles error: %v", err)
+	}
+	want := filepath.ToSlash(filepath.Join(dir, "src", "app.test.ts"))
+	if len(files) != 1 || files[0] != want {
+		t.Errorf("files = %v, want [%s]", files, want)
+	}
+}
+
+func TestScanGitignoreNegation(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ".ignore"), []byte("*Test.php\n!KeepTest.php\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "DropTest.php"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(dir, "KeepTest.php"), []byte(""), 0644)
+
+	files, err := ScanFiles([]string{dir}, "*Test.php")
+	if err != nil {
+		t.Fatalf("ScanFiles error: %v", err)
+	}
+	if len(files) != 1 || filepath.Base(files[0]) != "KeepTest.php" {
+		t.Errorf("files = %v, want only KeepTest.php", files)
+	}
+}
+
+func TestScanTargetFilesExclude(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "tests", "Fixtures"), 0755)
+	os.MkdirAll(filepath.Join(dir, "tests", "Unit"), 0755)
+	os.WriteFile(filepath.Join(dir, "tests", "Fixtures", "FakeTest.php"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(dir, "tests", "Unit", "UserTest.php"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(dir, "tests", "Unit", "SlowTest.php"), []byte(""), 0644)
+
+	files, err := ScanTargetFiles(config.Target{
+		TestDirs:    []string{filepath.Join(dir, "tests")},
+		FilePattern: "*Test.php",
+		Exclude:     []string{"Fixtures/", "Unit/Slow*"},
+	})
+	if err != nil {
+		t.Fatalf("ScanTargetFiles error: %v", err)
+	}
+	if len(files) != 1 || filepath.Base(files[0]) != "UserTest.php" {
+		t.Errorf("files = %v, want only UserTest.php", files)
+	}
+}
+
+func TestScanDoublestarPattern(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "src", "a", "__tests__"), 0755)
+	os.MkdirAll(filepath.Join(dir, "src", "__tests__"), 0755)
+	os.MkdirAll(filepath.Join(dir, "lib", "__tests__"), 0755)
+	os.WriteFile(filepath.Join(dir, "src", "a", "__tests__", "deep.ts"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(dir, "src", "__tests__", "shallow.ts"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(dir, "lib", "__tests__", "other.ts"), []byte(""), 0644)
+
+	files, err := ScanFiles([]string{dir}, "src/**/__tests__/*.ts")
+	if err != nil {
+		t.Fatalf("ScanFiles error: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("got %d files, want 2: %v", len(files), files)
+	}
+}
+
+func TestScanAllTargetsFilesAndSuites(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "tests", "Fixtures"), 0755)
+	os.MkdirAll(filepath.Join(dir, "legacy"), 0755)
+	os.WriteFile(filepath.Join(dir, "tests", "UserTest.php"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(dir, "tests", "Fixtures", "FakeTest.php"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(dir, "legacy", "Check.php"), []byte(""), 0644)
+
+	root := filepath.ToSlash(dir)
+	targets := []config.Target{{
+		Name:        "phpunit",
+		TestDirs:    []string{root + "/tests"},
+		FilePattern: "*Test.php",
+		Exclude:     []string{"Fixtures/"},
+		Files:       []string{root + "/legacy/Check.php", root + "/legacy/Missing.php"},
+		Suites:      []string{"Unit"},
+	}}
+
+	files, err := ScanAllTargets(targets)
+	if err != nil {
+		t.Fatalf("ScanAllTargets error: %v", err)
+	}
+	var paths []string
+	var suites []string
+	for _, f := range files {
+		if f.IsSuite() {
+			suites = append(suites, f.Suite)
+			continue
+		}
+		paths = append(paths, f.Path)
+	}
+	want := []string{root + "/legacy/Check.php", root + "/tests/UserTest.php"}
+	if len(paths) != 2 || paths[0] != want[0] || paths[1] != want[1] {
+		t.Errorf("paths = %v, want %v", paths, want)
+	}
+	if len(suites) != 1 || suites[0] != "Unit" {
+		t.Errorf("suites = %v, want [Unit]", suites)
+	}
+}
+
+func TestScanCargoDefaultsSkipHelperModules(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "tests", "common"), 0755)
+	os.WriteFile(filepath.Join(dir, "tests", "api.rs"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(dir, "tests", "common", "mod.rs"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(dir, ".lazytest.yml"), []byte(`targets:
+  - name: cargo
+    type: cargo
+`), 0644)
+	t.Chdir(dir)
+
+	cfg, err := config.Load(".lazytest.yml")
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	files, err := ScanAllTargets(cfg.Targets)
+	if err != nil {
+		t.Fatalf("ScanAllTargets error: %v", err)
+	}
+	if len(files) != 1 || files[0].Path != "tests/api.rs" {
+		t.Errorf("files = %v, want only tests/api.rs", files)
+	}
+}
@@ -1,6 +1,9 @@
 package domain
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // TestStatus represents the status of a test case.
 type TestStatus int
@@ -55,6 +58,8 @@ type TestCase struct {
 	Duration time.Duration
 	Message  string // failure message
 	Details  string // stack trace or additional details
+	Output   string // captured stdout/stderr
+	Location string // where the test is defined, as reported by the runner
 }
 
 // TestSuite represents a group of test cases (typically one test class).
@@ -63,18 +68,49 @@ type TestSuite struct {
 	Tests    []*TestCase
 	Status   TestStatus
 	Duration time.Duration
+	Location string // where the suite is defined, as reported by the runner
 }
 
 // TestFile represents a test file with its previous run status.
+// A TestFile with Suite set stands for a named test suite of its target
+// (e.g. a phpunit.xml <testsuite>) rather than a file; Path then holds a
+// display label.
 type TestFile struct {
 	Path       string     // relative path
 	TargetName string     // which target this file belongs to
+	TargetType string     // the target's framework type (e.g. "vitest")
 	PrevStatus TestStatus // status from previous run
+	Suite      string     // test suite name, for suite entries
+}
+
+// SuitePathPrefix prefixes the Path label of suite entries.
+const SuitePathPrefix = "testsuite:"
+
+// IsSuite reports whether f is a test suite entry rather than a file.
+func (f TestFile) IsSuite() bool {
+	return f.Suite != ""
+}
+
+// SetEntry names f in the Files of a TestSet: its target and path, as in
+// "api:testsuite:Unit", since suite labels repeat across targets.
+func (f TestFile) SetEntry() string {
+	return f.TargetName + ":" + f.Path
+}
+
+// TestSet is a named selection of tests, such as "checkout-flow": the
+// files picked in search mode together with the query they were picked
+// with. A set without files stands for every file its query matches.
+// Files holds SetEntry values; a bare path matches in any target.
+type TestSet struct {
+	Name  string   `yaml:"name" json:"name"`
+	Query string   `yaml:"query" json:"query,omitempty"`
+	Files []string `yaml:"files" json:"files,omitempty"`
 }
 
 // TestRun represents the results of a single test execution (one target).
 type TestRun struct {
 	TargetName string
+	TargetType string
 	Suites     []*TestSuite
 	Passed     int
 	Failed     int
@@ -136,3 +172,18 @@ func (s *TestSuite) ComputeStatus() TestStatus {
 	}
 	return StatusPassed
 }
+
+// RunName names the run of one matrix variant of a target, e.g.
+// "phpunit[php8.2]".
+func RunName(target, variant string) string {
+	return target + "[" + variant + "]"
+}
+
+// SplitRunName splits a run name into its target and matrix variant. The
+// variant is empty for the run of a target without a matrix.
+func SplitRunName(name string) (target, variant string) {
+	if i := strings.LastIndex(name, "["); i > 0 && strings.HasSuffix(name, "]") {
+		return name[:i], name[i+1 : len(name)-1]
+	}
+	return name, ""
+}
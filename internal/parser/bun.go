@@ -0,0 +1,101 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	bunHeaderRe = regexp.MustCompile(`^bun test v\S+`)
+	bunFileRe   = regexp.MustCompile(`^(\S.*\.[cm]?[jt]sx?):$`)
+	bunResultRe = regexp.MustCompile(`^\((pass|fail|skip|todo)\) (.+?)(?: \[([0-9.]+)(ms|s)\])?$`)
+)
+
+// BunParser parses the output of `bun test` (with stderr merged into
+// stdout) into Events. Each test file becomes a suite. Bun prints a failing
+// test's error before its "(fail)" line, so recent output is kept as the
+// failure details.
+type BunParser struct {
+	events       chan<- *Event
+	currentSuite string
+	recent       []string // output since the last result line
+}
+
+// NewBunParser creates a new bun test parser that sends events to the given channel.
+func NewBunParser(events chan<- *Event) *BunParser {
+	return &BunParser{events: events}
+}
+
+// ParseLine processes a single line of bun test output.
+func (p *BunParser) ParseLine(line string) {
+	trimmed := strings.TrimRight(line, "\r")
+	if bunHeaderRe.MatchString(trimmed) {
+		return
+	}
+
+	if m := bunFileRe.FindStringSubmatch(trimmed); m != nil {
+		p.finishSuite()
+		p.currentSuite = m[1]
+		p.events <- &Event{Type: EventSuiteStarted, Name: p.currentSuite}
+		return
+	}
+
+	m := bunResultRe.FindStringSubmatch(trimmed)
+	if m == nil || p.currentSuite == "" {
+		if strings.TrimSpace(trimmed) != "" {
+			p.recent = append(p.recent, trimmed)
+			p.events <- &Event{Type: EventOutput, RawLine: line}
+		}
+		return
+	}
+
+	name := m[2]
+	var dur time.Duration
+	if v, err := strconv.ParseFloat(m[3], 64); err == nil {
+		unit := time.Millisecond
+		if m[4] == "s" {
+			unit = time.Second
+		}
+		dur = time.Duration(v * float64(unit))
+	}
+
+	p.events <- &Event{Type: EventTestStarted, Name: name}
+	switch m[1] {
+	case "fail":
+		message, details := bunFailure(p.recent)
+		p.events <- &Event{Type: EventTestFailed, Name: name, Message: message, Details: details}
+	case "skip", "todo":
+		p.events <- &Event{Type: EventTestIgnored, Name: name, Message: m[1]}
+	}
+	p.events <- &Event{Type: EventTestFinished, Name: name, Duration: dur}
+	p.recent = nil
+}
+
+func (p *BunParser) finishSuite() {
+	p.recent = nil
+	if p.currentSuite == "" {
+		return
+	}
+	p.events <- &Event{Type: EventSuiteFinished, Name: p.currentSuite}
+	p.currentSuite = ""
+}
+
+// Flush finalizes the parser, closing the last suite.
+func (p *BunParser) Flush() {
+	p.finishSuite()
+}
+
+// bunFailure uses the "error:" line of a failure's output as the message
+// and the whole output as details.
+func bunFailure(lines []string) (message, details string) {
+	message = "Test failed"
+	for _, l := range lines {
+		if s := strings.TrimSpace(l); strings.HasPrefix(s, "error:") {
+			message = strings.TrimSpace(strings.TrimPrefix(s, "error:"))
+			break
+		}
+	}
+	return message, strings.Join(lines, "\n")
+}
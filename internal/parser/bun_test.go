@@ -0,0 +1,51 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBunStream(t *testing.T) {
+	input := `bun test v1.1.30 (7996d06b)
+
+src/math.test.ts:
+(pass) math > adds [0.08ms]
+4 |   expect(1 - 1).toBe(2);
+error: expect(received).toBe(expected)
+
+Expected: 2
+Received: 0
+
+      at <anonymous> (/app/src/math.test.ts:4:17)
+(fail) math > subtracts [1.50ms]
+(skip) math > later
+(todo) math > someday
+
+src/str.test.ts:
+(pass) upper [2.00s]
+
+ 2 pass
+ 2 skip
+ 1 fail
+Ran 5 tests across 2 files. [12.00ms]`
+
+	run := BuildTestRun(collectStreamEvents(input))
+	if len(run.Suites) != 2 || run.Suites[0].Name != "src/math.test.ts" {
+		t.Fatalf("suites = %+v, want src/math.test.ts and src/str.test.ts", run.Suites)
+	}
+	if run.Passed != 2 || run.Failed != 1 || run.Skipped != 2 {
+		t.Fatalf("passed/failed/skipped = %d/%d/%d, want 2/1/2", run.Passed, run.Failed, run.Skipped)
+	}
+
+	failed := run.Suites[0].Tests[1]
+	if failed.Name != "math > subtracts" || failed.Message != "expect(received).toBe(expected)" {
+		t.Errorf("failed test = %q: %q", failed.Name, failed.Message)
+	}
+	if !strings.Contains(failed.Details, "Received: 0") {
+		t.Errorf("details = %q, want the error output", failed.Details)
+	}
+	if d := run.Suites[1].Tests[0].Duration; d != 2*time.Second {
+		t.Errorf("duration = %v, want 2s", d)
+	}
+}
@@ -0,0 +1,175 @@
+package parser
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// goTestEvent is one line of `go test -json` (test2json) output.
+type goTestEvent struct {
+	Action      string
+	Package     string
+	Test        string
+	Output      string
+	OutputType  string
+	Elapsed     float64
+	ImportPath  string
+	FailedBuild string
+}
+
+// GoTestParser parses `go test -json` output into Events. Each package
+// becomes a suite; subtests are reported as tests named "TestX/sub".
+type GoTestParser struct {
+	events         chan<- *Event
+	currentPackage string
+	testOutput     map[string][]string // output lines per running test
+	packageOutput  []string
+	buildOutput    map[string][]string // compiler output per failed build
+	packageFailed  bool                // a test of the current package failed
+}
+
+// NewGoTestParser creates a new go test JSON parser that sends events to the given channel.
+func NewGoTestParser(events chan<- *Event) *GoTestParser {
+	return &GoTestParser{
+		events:      events,
+		testOutput:  make(map[string][]string),
+		buildOutput: make(map[string][]string),
+	}
+}
+
+// ParseLine processes a single line of go test -json output.
+func (p *GoTestParser) ParseLine(line string) {
+	trimmed := strings.TrimSpace(line)
+	var ev goTestEvent
+	if !strings.HasPrefix(trimmed, "{") || json.Unmarshal([]byte(trimmed), &ev) != nil || ev.Action == "" {
+		if trimmed != "" {
+			p.events <- &Event{Type: EventOutput, RawLine: line}
+		}
+		return
+	}
+
+	switch ev.Action {
+	case "build-output":
+		p.buildOutput[ev.ImportPath] = append(p.buildOutput[ev.ImportPath], strings.TrimRight(ev.Output, "\n"))
+		return
+	case "build-fail":
+		return
+	}
+	if ev.Package == "" {
+		return
+	}
+
+	if ev.Package != p.currentPackage {
+		p.finishPackage()
+		p.currentPackage = ev.Package
+		p.events <- &Event{Type: EventSuiteStarted, Name: ev.Package}
+	}
+
+	if ev.Test == "" {
+		p.handlePackageEvent(ev)
+		return
+	}
+
+	duration := time.Duration(ev.Elapsed * float64(time.Second))
+	switch ev.Action {
+	case "run":
+		p.testOutput[ev.Test] = nil
+		p.events <- &Event{Type: EventTestStarted, Name: ev.Test}
+	case "output":
+		if !isGoTestFrame(ev) {
+			p.testOutput[ev.Test] = append(p.testOutput[ev.Test], strings.TrimRight(ev.Output, "\n"))
+		}
+	case "pass":
+		delete(p.testOutput, ev.Test)
+		p.events <- &Event{Type: EventTestFinished, Name: ev.Test, Duration: duration}
+	case "fail":
+		p.packageFailed = true
+		message, details := goTestFailure(p.testOutput[ev.Test])
+		delete(p.testOutput, ev.Test)
+		p.events <- &Event{Type: EventTestFailed, Name: ev.Test, Message: message, Details: details}
+		p.events <- &Event{Type: EventTestFinished, Name: ev.Test, Duration: duration}
+	case "skip":
+		message, _ := goTestFailure(p.testOutput[ev.Test])
+		delete(p.testOutput, ev.Test)
+		p.events <- &Event{Type: EventTestIgnored, Name: ev.Test, Message: message}
+		p.events <- &Event{Type: EventTestFinished, Name: ev.Test, Duration: duration}
+	}
+}
+
+// handlePackageEvent handles events not tied to a test. A package that fails
+// without a failing test (build errors, panics in TestMain, timeouts) is
+// reported as a failed pseudo-test so the failure isn't lost.
+func (p *GoTestParser) handlePackageEvent(ev goTestEvent) {
+	switch ev.Action {
+	case "output":
+		if !isGoTestFrame(ev) {
+			p.packageOutput = append(p.packageOutput, strings.TrimRight(ev.Output, "\n"))
+		}
+	case "fail":
+		if p.packageFailed {
+			break
+		}
+		name := "(package)"
+		output := p.packageOutput
+		if ev.FailedBuild != "" {
+			name = "(build)"
+			output = p.buildOutput[ev.FailedBuild]
+		}
+		message, details := goTestFailure(output)
+		if message == "" {
+			message = strings.TrimSpace(ev.Output)
+		}
+		p.events <- &Event{Type: EventTestStarted, Name: name}
+		p.events <- &Event{Type: EventTestFailed, Name: name, Message: message, Details: details}
+		p.events <- &Event{Type: EventTestFinished, Name: name}
+	}
+}
+
+// finishPackage closes the current package's suite.
+func (p *GoTestParser) finishPackage() {
+	if p.currentPackage == "" {
+		return
+	}
+	p.events <- &Event{Type: EventSuiteFinished, Name: p.currentPackage}
+	p.currentPackage = ""
+	p.packageOutput = nil
+	p.packageFailed = false
+	p.testOutput = make(map[string][]string)
+}
+
+// Flush finalizes the parser, closing the last suite.
+func (p *GoTestParser) Flush() {
+	p.finishPackage()
+}
+
+// isGoTestFrame reports whether an output event is test framing such as
+// "=== RUN" or "--- PASS" rather than output of the test itself. Older Go
+// versions don't set OutputType, so the text is checked as well.
+func isGoTestFrame(ev goTestEvent) bool {
+	if ev.OutputType == "frame" {
+		return true
+	}
+	if ev.OutputType != "" {
+		return false
+	}
+	out := strings.TrimSpace(ev.Output)
+	for _, prefix := range []string{"=== ", "--- PASS", "--- FAIL", "--- SKIP", "PASS", "FAIL", "ok "} {
+		if strings.HasPrefix(out, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// goTestFailure returns the first non-empty output line as the message and
+// the whole output as details. Compiler "# package" headers are skipped.
+func goTestFailure(lines []string) (message, details string) {
+	for _, l := range lines {
+		if s := strings.TrimSpace(l); s != "" && !strings.HasPrefix(s, "# ") {
+			message = s
+			break
+		}
+	}
+	return message, strings.Join(lines, "\n")
+}
@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func collectStreamEvents(input string) []*Event {
+	events := make(chan *Event, 100)
+	go ParseStream(strings.NewReader(input), events)
+
+	var collected []*Event
+	for ev := range events {
+		if ev.Type != EventOutput {
+			collected = append(collected, ev)
+		}
+	}
+	return collected
+}
+
+func TestGoTestStream(t *testing.T) {
+	input := `{"Action":"start","Package":"app"}
+{"Action":"run","Package":"app","Test":"TestPass"}
+{"Action":"output","Package":"app","Test":"TestPass","Output":"=== RUN   TestPass\n","OutputType":"frame"}
+{"Action":"pass","Package":"app","Test":"TestPass","Elapsed":0.25}
+{"Action":"run","Package":"app","Test":"TestFail"}
+{"Action":"output","Package":"app","Test":"TestFail","Output":"    a_test.go:4: bad 1\n","OutputType":"error"}
+{"Action":"output","Package":"app","Test":"TestFail","Output":"--- FAIL: TestFail (0.00s)\n","OutputType":"frame"}
+{"Action":"fail","Package":"app","Test":"TestFail","Elapsed":0}
+{"Action":"run","Package":"app","Test":"TestSkip"}
+{"Action":"output","Package":"app","Test":"TestSkip","Output":"    a_test.go:5: later\n"}
+{"Action":"skip","Package":"app","Test":"TestSkip","Elapsed":0}
+{"Action":"output","Package":"app","Output":"FAIL\tapp\t0.002s\n","OutputType":"frame"}
+{"Action":"fail","Package":"app","Elapsed":0.003}`
+
+	events := collectStreamEvents(input)
+	run := BuildTestRun(events)
+	if run.Passed != 1 || run.Failed != 1 || run.Skipped != 1 {
+		t.Fatalf("passed/failed/skipped = %d/%d/%d, want 1/1/1", run.Passed, run.Failed, run.Skipped)
+	}
+	if len(run.Suites) != 1 || run.Suites[0].Name != "app" {
+		t.Fatalf("suites = %+v, want one suite 'app'", run.Suites)
+	}
+
+	tests := run.Suites[0].Tests
+	if tests[0].Duration != 250*time.Millisecond {
+		t.Errorf("TestPass duration = %v, want 250ms", tests[0].Duration)
+	}
+	if tests[1].Message != "a_test.go:4: bad 1" {
+		t.Errorf("TestFail message = %q", tests[1].Message)
+	}
+	if tests[2].Message != "a_test.go:5: later" {
+		t.Errorf("TestSkip message = %q", tests[2].Message)
+	}
+}
+
+func TestGoTestBuildFailure(t *testing.T) {
+	input := `{"ImportPath":"app/sub [app/sub.test]","Action":"build-output","Output":"# app/sub [app/sub.test]\n"}
+{"ImportPath":"app/sub [app/sub.test]","Action":"build-output","Output":"sub/b_test.go:3:27: declared and not used: x\n"}
+{"ImportPath":"app/sub [app/sub.test]","Action":"build-fail"}
+{"Action":"start","Package":"app/sub"}
+{"Action":"output","Package":"app/sub","Output":"FAIL\tapp/sub [build failed]\n","OutputType":"frame"}
+{"Action":"fail","Package":"app/sub","Elapsed":0,"FailedBuild":"app/sub [app/sub.test]"}`
+
+	run := BuildTestRun(collectStreamEvents(input))
+	if run.Failed != 1 {
+		t.Fatalf("Failed = %d, want 1", run.Failed)
+	}
+	tc := run.Suites[0].Tests[0]
+	if tc.Name != "(build)" || tc.Message != "sub/b_test.go:3:27: declared and not used: x" {
+		t.Errorf("build failure = %q: %q", tc.Name, tc.Message)
+	}
+}
@@ -0,0 +1,131 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	libtestRunningRe = regexp.MustCompile(`^\s+Running (?:unittests )?(\S+) \(`)
+	libtestDocRe     = regexp.MustCompile(`^\s+Doc-tests (\S+)`)
+	libtestCountRe   = regexp.MustCompile(`^running \d+ tests?$`)
+	libtestResultRe  = regexp.MustCompile(`^test (.+) \.\.\. (ok|FAILED|ignored)(?:, (.*))?$`)
+	libtestOutputRe  = regexp.MustCompile(`^---- (.+) std(?:out|err) ----$`)
+	libtestPanicRe   = regexp.MustCompile(`^thread '.*' panicked at `)
+)
+
+// LibtestParser parses the human-readable output of Rust's test harness
+// (`cargo test`) into Events. Each test binary becomes a suite, named after
+// the source file cargo reports in its "Running" line.
+type LibtestParser struct {
+	events       chan<- *Event
+	nextSuite    string
+	currentSuite string
+	failureTest  string   // test whose captured output is being read
+	failureLines []string // captured output of failureTest
+}
+
+// NewLibtestParser creates a new libtest parser that sends events to the given channel.
+func NewLibtestParser(events chan<- *Event) *LibtestParser {
+	return &LibtestParser{events: events}
+}
+
+// ParseLine processes a single line of cargo test output.
+func (p *LibtestParser) ParseLine(line string) {
+	if m := libtestRunningRe.FindStringSubmatch(line); m != nil {
+		p.finishSuite()
+		p.nextSuite = m[1]
+		return
+	}
+	if m := libtestDocRe.FindStringSubmatch(line); m != nil {
+		p.finishSuite()
+		p.nextSuite = "doc-tests " + m[1]
+		return
+	}
+
+	trimmed := strings.TrimRight(line, "\r")
+	if libtestCountRe.MatchString(trimmed) {
+		p.finishSuite()
+		p.currentSuite = p.nextSuite
+		if p.currentSuite == "" {
+			p.currentSuite = "tests"
+		}
+		p.nextSuite = ""
+		p.events <- &Event{Type: EventSuiteStarted, Name: p.currentSuite}
+		return
+	}
+
+	if p.currentSuite == "" {
+		if strings.TrimSpace(trimmed) != "" {
+			p.events <- &Event{Type: EventOutput, RawLine: line}
+		}
+		return
+	}
+
+	if m := libtestOutputRe.FindStringSubmatch(trimmed); m != nil {
+		p.emitFailure()
+		p.failureTest = m[1]
+		return
+	}
+	if trimmed == "failures:" || strings.HasPrefix(trimmed, "test result:") {
+		p.emitFailure()
+		if strings.HasPrefix(trimmed, "test result:") {
+			p.finishSuite()
+		}
+		return
+	}
+	if p.failureTest != "" {
+		p.failureLines = append(p.failureLines, trimmed)
+		return
+	}
+
+	if m := libtestResultRe.FindStringSubmatch(trimmed); m != nil {
+		name := m[1]
+		p.events <- &Event{Type: EventTestStarted, Name: name}
+		switch m[2] {
+		case "FAILED":
+			p.events <- &Event{Type: EventTestFailed, Name: name, Message: "Test failed"}
+		case "ignored":
+			p.events <- &Event{Type: EventTestIgnored, Name: name, Message: m[3]}
+		}
+		p.events <- &Event{Type: EventTestFinished, Name: name}
+		return
+	}
+
+	if strings.TrimSpace(trimmed) != "" {
+		p.events <- &Event{Type: EventOutput, RawLine: line}
+	}
+}
+
+// emitFailure reports the captured output of a failed test, which libtest
+// prints after all results of the binary, as that test's failure details.
+func (p *LibtestParser) emitFailure() {
+	if p.failureTest == "" {
+		return
+	}
+	details := strings.TrimSpace(strings.Join(p.failureLines, "\n"))
+	message := "Test failed"
+	for i, l := range p.failureLines {
+		if libtestPanicRe.MatchString(l) && i+1 < len(p.failureLines) {
+			message = strings.TrimSpace(p.failureLines[i+1])
+			break
+		}
+	}
+	p.events <- &Event{Type: EventTestFailed, Name: p.failureTest, Message: message, Details: details}
+	p.failureTest = ""
+	p.failureLines = nil
+}
+
+func (p *LibtestParser) finishSuite() {
+	p.emitFailure()
+	if p.currentSuite == "" {
+		return
+	}
+	p.events <- &Event{Type: EventSuiteFinished, Name: p.currentSuite}
+	p.currentSuite = ""
+}
+
+// Flush finalizes the parser, emitting pending failure details and closing the last suite.
+func (p *LibtestParser) Flush() {
+	p.finishSuite()
+}
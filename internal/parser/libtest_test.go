@@ -0,0 +1,61 @@
+package parser
+
+import "testing"
+
+func TestLibtestStream(t *testing.T) {
+	input := `   Compiling crt v0.1.0 (/tmp/crt)
+    Finished ` + "`test`" + ` profile [unoptimized + debuginfo] target(s) in 1.74s
+     Running tests/math.rs (target/debug/deps/math-fe15abbab2672b71)
+
+running 4 tests
+test adds ... ok
+test prints ... FAILED
+test slow ... ignored, slow
+test subs ... FAILED
+
+failures:
+
+---- prints stdout ----
+hello out
+
+thread 'prints' panicked at tests/math.rs:9:38:
+assertion failed: false
+
+---- subs stdout ----
+
+thread 'subs' panicked at tests/math.rs:4:13:
+assertion ` + "`left == right`" + ` failed: bad sub
+  left: 0
+ right: 2
+
+
+failures:
+    prints
+    subs
+
+test result: FAILED. 1 passed; 2 failed; 1 ignored; 0 measured; 0 filtered out; finished in 0.01s
+`
+
+	events := collectStreamEvents(input)
+	run := BuildTestRun(events)
+	if len(run.Suites) != 1 || run.Suites[0].Name != "tests/math.rs" {
+		t.Fatalf("suites = %+v, want one suite 'tests/math.rs'", run.Suites)
+	}
+	if run.Passed != 1 || run.Failed != 2 || run.Skipped != 1 {
+		t.Fatalf("passed/failed/skipped = %d/%d/%d, want 1/2/1", run.Passed, run.Failed, run.Skipped)
+	}
+
+	tests := run.Suites[0].Tests
+	if tests[1].Message != "assertion failed: false" {
+		t.Errorf("prints message = %q", tests[1].Message)
+	}
+	if tests[3].Message != "assertion `left == right` failed: bad sub" {
+		t.Errorf("subs message = %q", tests[3].Message)
+	}
+	if tests[2].Message != "slow" {
+		t.Errorf("slow ignore reason = %q", tests[2].Message)
+	}
+	if last := events[len(events)-1]; last.Type != EventSuiteFinished {
+		t.Errorf("last event = %+v, want SuiteFinished", last)
+	}
+}
@@ -21,6 +21,7 @@ const (
 	EventTestFailed
 	EventTestIgnored
 	EventOutput
+	EventTestOutput // captured stdout/stderr of a test, in Message
 )
 
 // Event represents a parsed TeamCity event.
@@ -30,6 +31,7 @@ type Event struct {
 	Duration time.Duration
 	Message  string
 	Details  string
+	Location string // locationHint of a started test or suite, e.g. "file:///app/a.test.ts:12"
 	RawLine  string
 }
 
@@ -57,11 +59,11 @@ func ParseLine(line string) *Event {
 
 	switch msgType {
 	case "testSuiteStarted":
-		return &Event{Type: EventSuiteStarted, Name: name, RawLine: line}
+		return &Event{Type: EventSuiteStarted, Name: name, Location: attrs["locationHint"], RawLine: line}
 	case "testSuiteFinished":
 		return &Event{Type: EventSuiteFinished, Name: name, RawLine: line}
 	case "testStarted":
-		return &Event{Type: EventTestStarted, Name: name, RawLine: line}
+		return &Event{Type: EventTestStarted, Name: name, Location: attrs["locationHint"], RawLine: line}
 	case "testFinished":
 		dur := parseDuration(attrs["duration"])
 		return &Event{Type: EventTestFinished, Name: name, Duration: dur, RawLine: line}
@@ -80,6 +82,13 @@ func ParseLine(line string) *Event {
 			Message: attrs["message"],
 			RawLine: line,
 		}
+	case "testStdOut", "testStdErr":
+		return &Event{
+			Type:    EventTestOutput,
+			Name:    name,
+			Message: attrs["out"],
+			RawLine: line,
+		}
 	default:
 		return nil
 	}
@@ -165,64 +174,71 @@ func parseDuration(s string) time.Duration {
 	return 0
 }
 
+// lineParser turns the lines of one output format into Events.
+type lineParser interface {
+	ParseLine(line string)
+	Flush()
+}
+
+// teamCityParser adapts ParseLine to the lineParser interface.
+type teamCityParser struct {
+	events chan<- *Event
+}
+
+func (p teamCityParser) ParseLine(line string) {
+	if ev := ParseLine(line); ev != nil {
+		p.events <- ev
+	} else {
+		p.events <- &Event{Type: EventOutput, RawLine: line}
+	}
+}
+
+func (p teamCityParser) Flush() {}
+
+// detectFormat returns a parser for the output format that line identifies,
+// or nil if the line isn't recognized.
+func detectFormat(line string, events chan<- *Event) lineParser {
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case strings.HasPrefix(trimmed, "##teamcity["):
+		return teamCityParser{events: events}
+	case strings.HasPrefix(trimmed, "TAP version") || tapPlanRe.MatchString(trimmed):
+		return NewTAPParser(events)
+	case strings.HasPrefix(trimmed, "{") && strings.Contains(trimmed, `"Action":`):
+		return NewGoTestParser(events)
+	case libtestRunningRe.MatchString(line) || libtestCountRe.MatchString(trimmed):
+		return NewLibtestParser(events)
+	case bunHeaderRe.MatchString(trimmed):
+		return NewBunParser(events)
+	}
+	return nil
+}
+
 // ParseStream reads from an io.Reader line by line and sends Events to the channel.
-// It auto-detects the output format (TeamCity or TAP) from the first meaningful line.
+// It auto-detects the output format (TeamCity, TAP, go test -json, cargo
+// test or bun test) from the first meaningful line.
 func ParseStream(r io.Reader, events chan<- *Event) {
 	defer close(events)
 	scanner := bufio.NewScanner(r)
 	// Increase buffer size for long lines
 	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
 
-	type streamFormat int
-	const (
-		formatUnknown streamFormat = iota
-		formatTeamCity
-		formatTAP
-	)
-
-	detected := formatUnknown
-	var tapParser *TAPParser
-
+	var p lineParser
 	for scanner.Scan() {
 		line := scanner.Text()
 
-		if detected == formatUnknown {
-			trimmed := strings.TrimSpace(line)
-			if strings.HasPrefix(trimmed, "##teamcity[") {
-				detected = formatTeamCity
-				ev := ParseLine(line)
-				if ev == nil {
-					events <- &Event{Type: EventOutput, RawLine: line}
-				} else {
-					events <- ev
-				}
-				continue
-			}
-			if strings.HasPrefix(trimmed, "TAP version") || tapPlanRe.MatchString(trimmed) {
-				detected = formatTAP
-				tapParser = NewTAPParser(events)
-				continue
-			}
-			// Not recognized yet - output as raw
-			events <- &Event{Type: EventOutput, RawLine: line}
-			continue
-		}
-
-		switch detected {
-		case formatTeamCity:
-			ev := ParseLine(line)
-			if ev == nil {
+		if p == nil {
+			if p = detectFormat(line, events); p == nil {
+				// Not recognized yet - output as raw
 				events <- &Event{Type: EventOutput, RawLine: line}
-			} else {
-				events <- ev
+				continue
 			}
-		case formatTAP:
-			tapParser.ParseLine(line)
 		}
+		p.ParseLine(line)
 	}
 
-	if tapParser != nil {
-		tapParser.Flush()
+	if p != nil {
+		p.Flush()
 	}
 }
 
@@ -235,9 +251,15 @@ func BuildTestRun(events []*Event) *domain.TestRun {
 	for _, ev := range events {
 		switch ev.Type {
 		case EventSuiteStarted:
+			// Reporters of parallel runners re-announce a suite to switch back to it.
+			if s, ok := suiteMap[ev.Name]; ok && s.Status == domain.StatusRunning {
+				currentSuite = s
+				continue
+			}
 			suite := &domain.TestSuite{
-				Name:   ev.Name,
-				Status: domain.StatusRunning,
+				Name:     ev.Name,
+				Status:   domain.StatusRunning,
+				Location: ev.Location,
 			}
 			suiteMap[ev.Name] = suite
 			run.Suites = append(run.Suites, suite)
@@ -251,9 +273,10 @@ func BuildTestRun(events []*Event) *domain.TestRun {
 		case EventTestStarted:
 			if currentSuite != nil {
 				tc := &domain.TestCase{
-					Name:   ev.Name,
-					Suite:  currentSuite.Name,
-					Status: domain.StatusRunning,
+					Name:     ev.Name,
+					Suite:    currentSuite.Name,
+					Status:   domain.StatusRunning,
+					Location: ev.Location,
 				}
 				currentSuite.Tests = append(currentSuite.Tests, tc)
 			}
@@ -294,6 +317,16 @@ func BuildTestRun(events []*Event) *domain.TestRun {
 					}
 				}
 			}
+
+		case EventTestOutput:
+			if currentSuite != nil {
+				for _, tc := range currentSuite.Tests {
+					if tc.Name == ev.Name {
+						tc.Output += ev.Message
+						break
+					}
+				}
+			}
 		}
 	}
 
@@ -157,3 +157,86 @@ func TestBuildTestRun(t *testing.T) {
 		t.Errorf("Suite status = %v, want Failed", run.Suites[0].Status)
 	}
 }
+
+func TestParseTestStdOut(t *testing.T) {
+	ev := ParseLine(`##teamcity[testStdErr name='test_bad|[1-2|]' out='boom|n']`)
+	if ev == nil || ev.Type != EventTestOutput {
+		t.Fatalf("got %+v, want EventTestOutput", ev)
+	}
+	if ev.Name != "test_bad[1-2]" || ev.Message != "boom\n" {
+		t.Errorf("name = %q, out = %q", ev.Name, ev.Message)
+	}
+}
+
+func TestBuildTestRunCollectsOutput(t *testing.T) {
+	events := []*Event{
+		{Type: EventSuiteStarted, Name: "tests/test_a.py"},
+		{Type: EventTestStarted, Name: "test_ok"},
+		{Type: EventTestOutput, Name: "test_ok", Message: "hello\n"},
+		{Type: EventTestOutput, Name: "test_ok", Message: "warning\n"},
+		{Type: EventTestFinished, Name: "test_ok"},
+		{Type: EventSuiteFinished, Name: "tests/test_a.py"},
+	}
+
+	run := BuildTestRun(events)
+	if got := run.Suites[0].Tests[0].Output; got != "hello\nwarning\n" {
+		t.Errorf("Output = %q, want both captured chunks", got)
+	}
+}
+
+func TestBuildTestRunSwitchesBackToRunningSuite(t *testing.T) {
+	input := `##teamcity[testSuiteStarted name='a.test.ts']
+##teamcity[testSuiteStarted name='b.test.ts']
+##teamcity[testSuiteStarted name='a.test.ts']
+##teamcity[testStarted name='one']
+##teamcity[testFinished name='one' duration='3']
+##teamcity[testSuiteStarted name='b.test.ts']
+##teamcity[testStarted name='two']
+##teamcity[testFailed name='two' message='boom' details='boom']
+##teamcity[testFinished name='two' duration='1']
+##teamcity[testSuiteStarted name='a.test.ts']
+##teamcity[testSuiteFinished name='a.test.ts']
+##teamcity[testSuiteStarted name='b.test.ts']
+##teamcity[testSuiteFinished name='b.test.ts']`
+
+	var events []*Event
+	for _, line := range strings.Split(input, "\n") {
+		events = append(events, ParseLine(line))
+	}
+
+	run := BuildTestRun(events)
+	if len(run.Suites) != 2 {
+		t.Fatalf("Suites = %d, want 2", len(run.Suites))
+	}
+	if len(run.Suites[0].Tests) != 1 || run.Suites[0].Tests[0].Name != "one" {
+		t.Errorf("a.test.ts tests = %+v, want [one]", run.Suites[0].Tests)
+	}
+	if len(run.Suites[1].Tests) != 1 || run.Suites[1].Status != domain.StatusFailed {
+		t.Errorf("b.test.ts = %+v, want one failed test", run.Suites[1])
+	}
+}
+
+func TestParseTestStartedLocationHint(t *testing.T) {
+	ev := ParseLine("##teamcity[testStarted name='works' locationHint='file:///app/src/a.test.ts:12']")
+	if ev == nil || ev.Location != "file:///app/src/a.test.ts:12" {
+		t.Fatalf("got %+v, want location file:///app/src/a.test.ts:12", ev)
+	}
+
+	run := BuildTestRun([]*Event{{Type: EventSuiteStarted, Name: "A"}, ev})
+	if got := run.Suites[0].Tests[0].Location; got != ev.Location {
+		t.Errorf("TestCase.Location = %q, want %q", got, ev.Location)
+	}
+}
+
+func TestParseSuiteStartedLocationHint(t *testing.T) {
+	ev := ParseLine(`##teamcity[testSuiteStarted name='Tests\FooTest' locationHint='php_qn:///app/tests/FooTest.php::\Tests\FooTest']`)
+	want := `php_qn:///app/tests/FooTest.php::\Tests\FooTest`
+	if ev == nil || ev.Location != want {
+		t.Fatalf("got %+v, want location %s", ev, want)
+	}
+
+	run := BuildTestRun([]*Event{ev})
+	if got := run.Suites[0].Location; got != want {
+		t.Errorf("TestSuite.Location = %q, want %q", got, want)
+	}
+}
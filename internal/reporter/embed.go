@@ -1,9 +1,15 @@
 package reporter
 
 import (
+	"crypto/sha256"
 	_ "embed"
+	"encoding/hex"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"github.com/meijin/lazytest/internal/state"
 )
 
 //go:embed vitest-reporter.mjs
@@ -12,18 +18,86 @@ var vitestReporterJS []byte
 //go:embed jest-reporter.js
 var jestReporterJS []byte
 
-// EnsureVitestReporter writes the embedded Vitest reporter to a temp file
-// and returns its path. The file is overwritten on each call to ensure
-// it matches the current binary version.
-func EnsureVitestReporter() (string, error) {
-	path := filepath.Join(os.TempDir(), "lazytest-vitest-reporter.mjs")
-	return path, os.WriteFile(path, vitestReporterJS, 0644)
+//go:embed rspec-reporter.rb
+var rspecReporterRB []byte
+
+//go:embed lazytest_pytest.py
+var pytestPluginPY []byte
+
+// pytestPluginModule is the module name to load the pytest plugin with -p.
+const pytestPluginModule = "lazytest_pytest"
+
+// embedded describes one reporter bundled into the binary.
+type embedded struct {
+	file string // file name; the content hash is inserted before the extension
+	data []byte
+	// module reports whether the reporter is loaded by module name, so the
+	// file keeps its name inside a hashed directory and the directory is
+	// returned instead.
+	module bool
+}
+
+var reporters = map[string]embedded{
+	"vitest": {file: "vitest-reporter.mjs", data: vitestReporterJS},
+	"jest":   {file: "jest-reporter.js", data: jestReporterJS},
+	"rspec":  {file: "rspec-reporter.rb", data: rspecReporterRB},
+	"pytest": {file: pytestPluginModule + ".py", data: pytestPluginPY, module: true},
+}
+
+// Has reports whether a reporter is embedded for framework.
+func Has(framework string) bool {
+	_, ok := reporters[framework]
+	return ok
+}
+
+// Ensure installs the embedded reporter for framework and returns the path
+// to use for {reporter}: the reporter file, or for pytest the directory to
+// put on PYTHONPATH. Reporters live in a per-user cache directory under a
+// name derived from their content, so an existing file is always current
+// and concurrent instances never clobber each other's files.
+func Ensure(framework string) (string, error) {
+	r, ok := reporters[framework]
+	if !ok {
+		return "", fmt.Errorf("{reporter}: no built-in reporter for %s targets", framework)
+	}
+
+	dir, err := cacheDir()
+	if err != nil {
+		return "", fmt.Errorf("installing %s reporter: %w", framework, err)
+	}
+
+	sum := sha256.Sum256(r.data)
+	hash := hex.EncodeToString(sum[:])[:12]
+	var path string
+	if r.module {
+		dir = filepath.Join(dir, strings.TrimSuffix(r.file, filepath.Ext(r.file))+"-"+hash)
+		path = filepath.Join(dir, r.file)
+	} else {
+		ext := filepath.Ext(r.file)
+		path = filepath.Join(dir, strings.TrimSuffix(r.file, ext)+"-"+hash+ext)
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return "", fmt.Errorf("installing %s reporter: %w", framework, err)
+		}
+		if err := state.WriteFileAtomic(path, r.data); err != nil {
+			return "", fmt.Errorf("installing %s reporter: %w", framework, err)
+		}
+	}
+
+	if r.module {
+		return dir, nil
+	}
+	return path, nil
 }
 
-// EnsureJestReporter writes the embedded Jest reporter to a temp file
-// and returns its path. The file is overwritten on each call to ensure
-// it matches the current binary version.
-func EnsureJestReporter() (string, error) {
-	path := filepath.Join(os.TempDir(), "lazytest-jest-reporter.js")
-	return path, os.WriteFile(path, jestReporterJS, 0644)
+// cacheDir returns the per-user directory reporters are installed in
+// ($XDG_CACHE_HOME/lazytest/reporters or the OS equivalent).
+func cacheDir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "lazytest", "reporters"), nil
 }
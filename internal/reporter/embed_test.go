@@ -0,0 +1,47 @@
+package reporter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEnsureWritesContentAddressedFile(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	path, err := Ensure("vitest")
+	if err != nil {
+		t.Fatalf("Ensure error: %v", err)
+	}
+	if base := filepath.Base(path); !strings.HasPrefix(base, "vitest-reporter-") || filepath.Ext(base) != ".mjs" {
+		t.Errorf("path = %q, want vitest-reporter-<hash>.mjs", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != string(vitestReporterJS) {
+		t.Fatalf("installed reporter differs from the embedded one (err %v)", err)
+	}
+
+	again, err := Ensure("vitest")
+	if err != nil || again != path {
+		t.Errorf("second Ensure = %q, %v; want %q", again, err, path)
+	}
+}
+
+func TestEnsurePytestReturnsModuleDir(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	dir, err := Ensure("pytest")
+	if err != nil {
+		t.Fatalf("Ensure error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "lazytest_pytest.py")); err != nil {
+		t.Errorf("plugin module missing from %q: %v", dir, err)
+	}
+}
+
+func TestEnsureUnknownFramework(t *testing.T) {
+	if _, err := Ensure("phpunit"); err == nil {
+		t.Error("Ensure(phpunit) = nil error, want an error")
+	}
+}
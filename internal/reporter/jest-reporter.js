@@ -1,9 +1,15 @@
 /**
  * LazyTest custom Jest reporter - TeamCity streaming output.
- * Emits TeamCity service messages per test result for real-time parsing.
- * Compatible with Jest 27+.
+ * Emits TeamCity service messages per test case as soon as it finishes
+ * (onTestCaseResult, Jest 29.6+), falling back to per-file output from
+ * onTestResult on older versions. Compatible with Jest 27+.
+ *
+ * Jest runs files in parallel, so results of different files interleave.
+ * A testSuiteStarted for an already started suite switches back to it.
  */
 
+const path = require("path");
+
 function escape(str) {
   if (!str) return "";
   return str
@@ -22,38 +28,100 @@ function msg(type, attrs) {
   process.stdout.write(`##teamcity[${type} ${parts}]\n`);
 }
 
+// Suites are named by the file's path relative to the project's rootDir, so
+// files sharing a name in different directories don't merge.
+function suiteNameOf(test) {
+  const filePath = test && test.path;
+  if (!filePath) return "unknown";
+  const rootDir = test.context && test.context.config && test.context.config.rootDir;
+  return rootDir ? path.relative(rootDir, filePath).split(path.sep).join("/") : filePath;
+}
+
+function testName(tc) {
+  return tc.ancestorTitles.length > 0
+    ? tc.ancestorTitles.join(" > ") + " > " + tc.title
+    : tc.title;
+}
+
+// Name of the pseudo-test that reports failures outside any test case.
+const SUITE_FAILURE_NAME = "Test suite failed to run";
+
 class LazyTestJestReporter {
-  onTestResult(_test, testResult) {
-    const filePath = testResult.testFilePath || "unknown";
-    const suiteName = filePath.split("/").pop();
-
-    msg("testSuiteStarted", { name: suiteName });
-
-    for (const tc of testResult.testResults) {
-      const name =
-        tc.ancestorTitles.length > 0
-          ? tc.ancestorTitles.join(" > ") + " > " + tc.title
-          : tc.title;
-
-      msg("testStarted", { name });
-
-      if (tc.status === "failed") {
-        const message = tc.failureMessages.join("\n") || "Test failed";
-        msg("testFailed", { name, message, details: message });
-      } else if (
-        tc.status === "pending" ||
-        tc.status === "skipped" ||
-        tc.status === "todo" ||
-        tc.status === "disabled"
-      ) {
-        msg("testIgnored", { name, message: tc.status });
+  constructor() {
+    this.currentSuite = null;
+    this.streamed = new Set(); // files whose cases came via onTestCaseResult
+  }
+
+  enterSuite(suiteName) {
+    if (this.currentSuite !== suiteName) {
+      msg("testSuiteStarted", { name: suiteName });
+      this.currentSuite = suiteName;
+    }
+  }
+
+  emitCase(tc) {
+    const name = testName(tc);
+
+    msg("testStarted", { name });
+
+    if (tc.status === "failed") {
+      const message = (tc.failureMessages || []).join("\n") || "Test failed";
+      msg("testFailed", { name, message, details: message });
+    } else if (
+      tc.status === "pending" ||
+      tc.status === "skipped" ||
+      tc.status === "todo" ||
+      tc.status === "disabled"
+    ) {
+      msg("testIgnored", { name, message: tc.status });
+    }
+
+    const duration = tc.duration || 0;
+    msg("testFinished", { name, duration });
+  }
+
+  onTestFileStart(test) {
+    this.enterSuite(suiteNameOf(test));
+  }
+
+  // Jest < 28 only calls onTestStart.
+  onTestStart(test) {
+    this.enterSuite(suiteNameOf(test));
+  }
+
+  onTestCaseResult(test, testCaseResult) {
+    this.streamed.add(test.path);
+    this.enterSuite(suiteNameOf(test));
+    this.emitCase(testCaseResult);
+  }
+
+  onTestResult(test, testResult) {
+    const suiteName = suiteNameOf(test);
+    this.enterSuite(suiteName);
+
+    if (!this.streamed.has(test.path)) {
+      for (const tc of testResult.testResults) {
+        this.emitCase(tc);
       }
+    }
+    this.streamed.delete(test.path);
 
-      const duration = tc.duration || 0;
-      msg("testFinished", { name, duration });
+    // Failures outside any test case: the file failed to load (syntax
+    // error, missing module) or a hook like beforeAll/afterAll threw.
+    const caseFailed = testResult.testResults.some((tc) => tc.status === "failed");
+    const execError = testResult.testExecError;
+    if (execError || (testResult.failureMessage && !caseFailed)) {
+      const message =
+        (execError && execError.message) || testResult.failureMessage || "Test suite failed";
+      const details =
+        testResult.failureMessage || (execError && execError.stack) || message;
+      msg("testStarted", { name: SUITE_FAILURE_NAME });
+      msg("testFailed", { name: SUITE_FAILURE_NAME, message, details });
+      msg("testFinished", { name: SUITE_FAILURE_NAME, duration: 0 });
     }
 
     msg("testSuiteFinished", { name: suiteName });
+    this.currentSuite = null;
   }
 }
 
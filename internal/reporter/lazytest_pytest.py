@@ -0,0 +1,151 @@
+"""
+LazyTest pytest plugin - TeamCity streaming output.
+Emits TeamCity service messages for each test as its phases finish.
+Each test module becomes a suite; test names are the node id within the
+module, including class and parametrize ids (e.g. "TestUser::test_age[18]").
+Load with: PYTHONPATH=<dir> python -m pytest -p lazytest_pytest
+Compatible with pytest 6+.
+"""
+
+import sys
+
+_config = None
+_current_suite = None
+_durations = {}
+_failed = set()
+
+
+def _escape(value):
+    return (
+        str(value)
+        .replace("|", "||")
+        .replace("'", "|'")
+        .replace("\n", "|n")
+        .replace("\r", "|r")
+        .replace("[", "|[")
+        .replace("]", "|]")
+    )
+
+
+def _msg(kind, **attrs):
+    parts = " ".join("%s='%s'" % (k, _escape(v)) for k, v in attrs.items())
+    line = "##teamcity[%s %s]" % (kind, parts)
+    # Go through the terminal reporter so messages never end up on the same
+    # line as its progress output.
+    reporter = _config.pluginmanager.get_plugin("terminalreporter") if _config else None
+    if reporter is not None:
+        reporter.write_line(line)
+        reporter.flush()
+    else:
+        sys.stdout.write(line + "\n")
+        sys.stdout.flush()
+
+
+def _split_nodeid(nodeid):
+    suite, sep, name = nodeid.partition("::")
+    return suite, (name if sep else suite)
+
+
+def _switch_suite(suite):
+    global _current_suite
+    if suite == _current_suite:
+        return
+    if _current_suite is not None:
+        _msg("testSuiteFinished", name=_current_suite)
+    _current_suite = suite
+    _msg("testSuiteStarted", name=suite)
+
+
+def _skip_reason(report):
+    longrepr = report.longrepr
+    if isinstance(longrepr, tuple) and len(longrepr) == 3:
+        reason = longrepr[2]
+    else:
+        reason = str(longrepr or "")
+    if reason.startswith("Skipped: "):
+        reason = reason[len("Skipped: "):]
+    return reason or "skipped"
+
+
+def _failure(report):
+    text = str(report.longrepr or "")
+    message = ""
+    for line in text.splitlines():
+        if line.startswith("E "):
+            message = line[2:].strip()
+            break
+    if not message and text:
+        message = text.strip().splitlines()[-1]
+    return message or "Test failed", text
+
+
+def pytest_configure(config):
+    global _config
+    _config = config
+
+
+def pytest_collectreport(report):
+    if not report.failed:
+        return
+    suite, _ = _split_nodeid(report.nodeid)
+    _switch_suite(suite or "collection")
+    name = "(collection)"
+    message, details = _failure(report)
+    _msg("testStarted", name=name)
+    _msg("testFailed", name=name, message="Collection failed: " + message, details=details)
+    _msg("testFinished", name=name, duration=0)
+
+
+def pytest_runtest_logreport(report):
+    suite, name = _split_nodeid(report.nodeid)
+    _durations[report.nodeid] = _durations.get(report.nodeid, 0) + report.duration
+    xfail_reason = getattr(report, "wasxfail", None)
+
+    if report.when == "setup":
+        _switch_suite(suite)
+        _msg("testStarted", name=name)
+        if report.failed:
+            message, details = _failure(report)
+            _failed.add(report.nodeid)
+            _msg("testFailed", name=name, message="Error in setup: " + message, details=details)
+        elif report.skipped:
+            if xfail_reason is not None:
+                _msg("testIgnored", name=name, message="xfail: " + (xfail_reason or "expected failure"))
+            else:
+                _msg("testIgnored", name=name, message=_skip_reason(report))
+
+    elif report.when == "call":
+        if xfail_reason is not None and report.skipped:
+            _msg("testIgnored", name=name, message="xfail: " + (xfail_reason or "expected failure"))
+        elif xfail_reason is not None and report.passed:
+            _msg("testStdOut", name=name, out="XPASS: expected to fail" + (": " + xfail_reason if xfail_reason else "") + "\n")
+        elif report.failed:
+            message, details = _failure(report)
+            _failed.add(report.nodeid)
+            _msg("testFailed", name=name, message=message, details=details)
+        elif report.skipped:
+            _msg("testIgnored", name=name, message=_skip_reason(report))
+
+    elif report.when == "teardown":
+        if report.failed:
+            message, details = _failure(report)
+            if report.nodeid in _failed:
+                # Keep the original failure; report the teardown error as output.
+                _msg("testStdErr", name=name, out="Error in teardown: " + details + "\n")
+            else:
+                _msg("testFailed", name=name, message="Error in teardown: " + message, details=details)
+        _failed.discard(report.nodeid)
+        # Captured output accumulates over all phases of the test.
+        if report.capstdout:
+            _msg("testStdOut", name=name, out=report.capstdout)
+        if report.capstderr:
+            _msg("testStdErr", name=name, out=report.capstderr)
+        duration = int(round(_durations.pop(report.nodeid, 0) * 1000))
+        _msg("testFinished", name=name, duration=duration)
+
+
+def pytest_sessionfinish(session, exitstatus):
+    global _current_suite
+    if _current_suite is not None:
+        _msg("testSuiteFinished", name=_current_suite)
+        _current_suite = None
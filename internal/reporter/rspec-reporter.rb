@@ -0,0 +1,74 @@
+# LazyTest custom RSpec formatter - TeamCity streaming output.
+# Emits TeamCity service messages per example for real-time parsing.
+# Each spec file becomes a suite. Compatible with RSpec 3+.
+
+require "rspec/core/formatters/base_formatter"
+
+class LazytestFormatter < RSpec::Core::Formatters::BaseFormatter
+  RSpec::Core::Formatters.register self,
+    :example_started, :example_passed, :example_failed, :example_pending, :close
+
+  def initialize(output)
+    super
+    @current_file = nil
+  end
+
+  def example_started(notification)
+    example = notification.example
+    file = example.file_path.sub(%r{\A\./}, "")
+    if file != @current_file
+      msg("testSuiteFinished", name: @current_file) if @current_file
+      @current_file = file
+      msg("testSuiteStarted", name: file)
+    end
+    msg("testStarted", name: example.full_description)
+  end
+
+  def example_passed(notification)
+    finish(notification.example)
+  end
+
+  def example_failed(notification)
+    example = notification.example
+    message = notification.message_lines.join("\n").strip
+    message = "Test failed" if message.empty?
+    details = notification.formatted_backtrace.join("\n")
+    msg("testFailed", name: example.full_description, message: message, details: details)
+    finish(example)
+  end
+
+  def example_pending(notification)
+    example = notification.example
+    reason = example.execution_result.pending_message || "pending"
+    msg("testIgnored", name: example.full_description, message: reason)
+    finish(example)
+  end
+
+  def close(_notification)
+    msg("testSuiteFinished", name: @current_file) if @current_file
+    @current_file = nil
+  end
+
+  private
+
+  def finish(example)
+    duration = ((example.execution_result.run_time || 0) * 1000).round
+    msg("testFinished", name: example.full_description, duration: duration)
+  end
+
+  def msg(type, attrs)
+    parts = attrs.map { |k, v| "#{k}='#{escape(v.to_s)}'" }.join(" ")
+    output.puts("##teamcity[#{type} #{parts}]")
+    output.flush
+  end
+
+  def escape(str)
+    str.gsub(/[|'\n\r\[\]]/) do |c|
+      case c
+      when "\n" then "|n"
+      when "\r" then "|r"
+      else "|#{c}"
+      end
+    end
+  end
+end
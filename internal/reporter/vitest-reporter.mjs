@@ -1,9 +1,13 @@
 /**
  * LazyTest custom Vitest reporter - TeamCity streaming output.
  * Emits TeamCity service messages on each test case result for real-time parsing.
- * Compatible with Vitest v2+ (Reported Tasks API).
+ * Console output, file locations, retries and run-level errors (failed
+ * imports, unhandled errors) are reported too.
+ * Compatible with Vitest v3+ (Reported Tasks API, onTestRunEnd).
  */
 
+import path from "node:path";
+
 function escape(str) {
   if (!str) return "";
   return str
@@ -22,7 +26,7 @@ function msg(type, attrs) {
   process.stdout.write(`##teamcity[${type} ${parts}]\n`);
 }
 
-function getSuiteName(testCase) {
+function getSuiteName(testCase, root) {
   const parts = [];
   let node = testCase.parent;
   while (node) {
@@ -38,23 +42,40 @@ function getSuiteName(testCase) {
   if (parts.length > 0) {
     return parts.join(" > ");
   }
-  // Fallback to module file name
-  const mod = testCase.module;
-  if (mod && mod.moduleId) {
-    return mod.moduleId.split("/").pop();
-  }
-  return "unknown";
+  // Fallback to the module's file
+  return moduleName(testCase.module, root);
+}
+
+// moduleName names a file-level suite by the file's path relative to root,
+// so files sharing a name in different directories don't merge.
+function moduleName(mod, root) {
+  const file = mod && mod.moduleId;
+  if (!file) return "unknown";
+  return root ? path.relative(root, file).split(path.sep).join("/") : file;
+}
+
+// Name of the pseudo-test that reports failures outside any test case.
+const FILE_FAILURE_NAME = "Test suite failed to run";
+
+function locationHint(testCase) {
+  const file = testCase.module?.moduleId;
+  if (!file) return "";
+  const line = testCase.location?.line;
+  return line ? `file://${file}:${line}` : `file://${file}`;
+}
+
+function errorText(error) {
+  const message = error?.message || String(error || "") || "Unknown error";
+  const details = error?.stack || message;
+  return { message, details };
 }
 
 export default class TeamCityStreamingReporter {
   #currentSuite = null;
+  #root = "";
+  #logs = new Map(); // task id -> console output captured before its result
 
-  onTestCaseResult(testCase) {
-    const result = testCase.result();
-    const name = testCase.name;
-    const suiteName = getSuiteName(testCase);
-
-    // Handle suite transitions
+  #enterSuite(suiteName) {
     if (this.#currentSuite !== suiteName) {
       if (this.#currentSuite !== null) {
         msg("testSuiteFinished", { name: this.#currentSuite });
@@ -62,8 +83,48 @@ export default class TeamCityStreamingReporter {
       msg("testSuiteStarted", { name: suiteName });
       this.#currentSuite = suiteName;
     }
+  }
 
+  #failedPseudoTest(name, error) {
+    const { message, details } = errorText(error);
     msg("testStarted", { name });
+    msg("testFailed", { name, message, details });
+    msg("testFinished", { name, duration: 0 });
+  }
+
+  onInit(vitest) {
+    this.#root = vitest?.config?.root || "";
+  }
+
+  onUserConsoleLog(log) {
+    if (!log.taskId) return;
+    const logs = this.#logs.get(log.taskId) || [];
+    logs.push(log);
+    this.#logs.set(log.taskId, logs);
+  }
+
+  onTestCaseResult(testCase) {
+    const result = testCase.result();
+    const name = testCase.name;
+    this.#enterSuite(getSuiteName(testCase, this.#root));
+
+    const hint = locationHint(testCase);
+    msg("testStarted", hint ? { name, locationHint: hint } : { name });
+
+    for (const log of this.#logs.get(testCase.id) || []) {
+      const type = log.type === "stderr" ? "testStdErr" : "testStdOut";
+      msg(type, { name, out: log.content });
+    }
+    this.#logs.delete(testCase.id);
+
+    const diagnostic = testCase.diagnostic?.() || {};
+    if (diagnostic.retryCount > 0) {
+      const flaky = diagnostic.flaky ? " (flaky)" : "";
+      msg("testStdOut", { name, out: `Retried ${diagnostic.retryCount} time(s)${flaky}\n` });
+    }
+    if (diagnostic.repeatCount > 0) {
+      msg("testStdOut", { name, out: `Repeated ${diagnostic.repeatCount} time(s)\n` });
+    }
 
     if (result.state === "failed") {
       const error = result.errors?.[0];
@@ -71,17 +132,37 @@ export default class TeamCityStreamingReporter {
       const details = error?.stack || "";
       msg("testFailed", { name, message, details });
     } else if (result.state === "skipped") {
-      msg("testIgnored", { name, message: "skipped" });
+      msg("testIgnored", { name, message: result.note || "skipped" });
     }
 
-    const duration = result.duration || 0;
+    const duration = result.duration || diagnostic.duration || 0;
     msg("testFinished", { name, duration });
   }
 
-  onTestRunEnd() {
+  onTestRunEnd(testModules = [], unhandledErrors = []) {
+    // Files that failed to collect (import or syntax errors, failing
+    // top-level hooks) have errors but no test results of their own.
+    for (const mod of testModules) {
+      const errors = typeof mod.errors === "function" ? mod.errors() : [];
+      if (errors.length === 0) continue;
+      this.#enterSuite(moduleName(mod, this.#root));
+      errors.forEach((error, i) => {
+        const name = errors.length > 1 ? `${FILE_FAILURE_NAME} (${i + 1})` : FILE_FAILURE_NAME;
+        this.#failedPseudoTest(name, error);
+      });
+    }
+
+    if (unhandledErrors.length > 0) {
+      this.#enterSuite("Unhandled errors");
+      unhandledErrors.forEach((error, i) => {
+        this.#failedPseudoTest(`Unhandled error ${i + 1}`, error);
+      });
+    }
+
     if (this.#currentSuite !== null) {
       msg("testSuiteFinished", { name: this.#currentSuite });
       this.#currentSuite = null;
     }
+    this.#logs.clear();
   }
 }
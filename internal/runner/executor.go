@@ -2,7 +2,9 @@ package runner
 
 import (
 	"context"
+	"os"
 	"os/exec"
+	"path"
 	"strings"
 	"sync"
 
@@ -22,20 +24,94 @@ type TargetEvent struct {
 
 // Executor manages test command execution across multiple targets.
 type Executor struct {
-	Targets            map[string]config.Target
-	vitestReporterPath string
-	jestReporterPath   string
+	// Targets holds the targets and their matrix variants by run name.
+	Targets map[string]config.Target
+	// runs holds the run names of each target with a matrix.
+	runs map[string][]string
+	// pathMaps holds the compiled path_map rules by run name.
+	pathMaps map[string]config.PathMapper
+	// reporterPaths and reporterErrs hold, per framework, the installed
+	// built-in reporter or why it couldn't be installed.
+	reporterPaths map[string]string
+	reporterErrs  map[string]error
 }
 
 // NewExecutor creates a new Executor with the given config.
 func NewExecutor(cfg config.Config) *Executor {
 	targets := make(map[string]config.Target)
+	runs := make(map[string][]string)
+	var all []config.Target
 	for _, t := range cfg.Targets {
 		targets[t.Name] = t
+		all = append(all, t)
+		if len(t.Matrix) == 0 {
+			continue
+		}
+		for _, run := range t.Runs() {
+			targets[run.Name] = run
+			runs[t.Name] = append(runs[t.Name], run.Name)
+			all = append(all, run)
+		}
+	}
+	pathMaps := make(map[string]config.PathMapper)
+	for _, t := range all {
+		// Invalid rules are reported by config validation.
+		if pm, err := config.CompilePathMapper(t.PathMap); err == nil && len(pm) > 0 {
+			pathMaps[t.Name] = pm
+		}
+	}
+	e := &Executor{
+		Targets:       targets,
+		runs:          runs,
+		pathMaps:      pathMaps,
+		reporterPaths: make(map[string]string),
+		reporterErrs:  make(map[string]error),
+	}
+
+	// Install the built-in reporters the targets' commands refer to.
+	for _, t := range all {
+		if !usesReporter(t) {
+			continue
+		}
+		fw := t.Framework()
+		if _, done := e.reporterPaths[fw]; done {
+			continue
+		}
+		if _, failed := e.reporterErrs[fw]; failed {
+			continue
+		}
+		path, err := reporter.Ensure(fw)
+		if err != nil {
+			e.reporterErrs[fw] = err
+			continue
+		}
+		e.reporterPaths[fw] = path
 	}
-	vitestReporterPath, _ := reporter.EnsureVitestReporter()
-	jestReporterPath, _ := reporter.EnsureJestReporter()
-	return &Executor{Targets: targets, vitestReporterPath: vitestReporterPath, jestReporterPath: jestReporterPath}
+	return e
+}
+
+// usesReporter reports whether the target's command needs a built-in reporter.
+func usesReporter(t config.Target) bool {
+	return strings.Contains(t.Command, "{reporter}")
+}
+
+// ReporterError returns why the built-in reporter needed by the target
+// couldn't be installed, or nil.
+func (e *Executor) ReporterError(targetName string) error {
+	target, ok := e.Targets[targetName]
+	if !ok || !usesReporter(target) {
+		return nil
+	}
+	return e.reporterErrs[target.Framework()]
+}
+
+// RunNames returns the names of the runs the files of a target are run
+// as: one per matrix variant ("phpunit[php8.2]"), or the target's own name.
+func (e *Executor) RunNames(targetName string) []string {
+	if names, ok := e.runs[targetName]; ok {
+		return names
+	}
+	return []string{targetName}
 }
 
 // BuildCommand constructs the full command string for a specific target.
@@ -47,6 +123,10 @@ func (e *Executor) BuildCommand(targetName string, files []string) string {
 
 	transformed := make([]string, len(files))
 	for i, f := range files {
+		if mapped, ok := e.pathMaps[targetName].ToRunner(f); ok {
+			transformed[i] = mapped
+			continue
+		}
 		if target.PathStripPrefix != "" {
 			f = strings.TrimPrefix(f, target.PathStripPrefix)
 		}
@@ -57,48 +137,120 @@ func (e *Executor) BuildCommand(targetName string, files []string) string {
 		transformed[i] = f
 	}
 
-	cmd := target.Command
-	joined := strings.Join(transformed, " ")
-	cmd = strings.ReplaceAll(cmd, "{files}", joined)
-
+	values := []string{
+		"{files}", strings.Join(transformed, " "),
+		"{dirs}", strings.Join(fileDirs(transformed), " "),
+		"{names}", strings.Join(fileNames(transformed), " "),
+	}
 	if len(transformed) > 0 {
-		cmd = strings.ReplaceAll(cmd, "{file}", transformed[0])
+		values = append(values, "{file}", transformed[0])
 	}
+	return e.fillCommand(target, values...)
+}
 
-	reporterPath := e.vitestReporterPath
-	if target.Name == "jest" {
-		reporterPath = e.jestReporterPath
+// fillCommand replaces the placeholders of target's command with the
+// placeholder, value pairs, and {reporter} with the target's built-in
+// reporter. Values are not searched for placeholders again.
+func (e *Executor) fillCommand(target config.Target, pairs ...string) string {
+	if usesReporter(target) {
+		pairs = append(pairs, "{reporter}", e.reporterPaths[target.Framework()])
 	}
-	cmd = strings.ReplaceAll(cmd, "{reporter}", reporterPath)
+	return strings.NewReplacer(pairs...).Replace(target.Command)
+}
 
-	return cmd
+// fileDirs returns the distinct directories of files in order of first
+// appearance, as "./dir" so tools like go test treat them as paths.
+func fileDirs(files []string) []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, f := range files {
+		dir := path.Dir(f)
+		if !path.IsAbs(dir) && dir != "." && !strings.HasPrefix(dir, "../") {
+			dir = "./" + dir
+		} else if dir == "." {
+			dir = "./"
+		}
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// fileNames returns the base names of files without their extension, e.g.
+// the integration test names cargo expects after --test.
+func fileNames(files []string) []string {
+	names := make([]string, len(files))
+	for i, f := range files {
+		base := path.Base(f)
+		names[i] = strings.TrimSuffix(base, path.Ext(base))
+	}
+	return names
+}
+
+// BuildSuiteCommand constructs the command that runs one named test suite of
+// a target: the file placeholders are replaced with a --testsuite option,
+// and the others as in BuildCommand.
+func (e *Executor) BuildSuiteCommand(targetName, suite string) string {
+	target, ok := e.Targets[targetName]
+	if !ok {
+		return ""
+	}
+	option := "--testsuite " + ShellQuote(suite)
+	return e.fillCommand(target, "{files}", option, "{file}", option, "{dirs}", option, "{names}", option)
+}
+
+// ShellQuote quotes s for use as a single sh word.
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// targetRun is the work to do for one target: its selected files and suites.
+type targetRun struct {
+	files  []string
+	suites []string
 }
 
 // Run executes test commands for all relevant targets in parallel.
-// Files are grouped by TargetName and each group runs in its own goroutine.
+// Files are grouped by TargetName and each group runs in its own goroutine,
+// one per matrix variant of targets with a matrix; events carry the run
+// name. Selected test suites run after the target's files, one command per
+// suite.
 func (e *Executor) Run(ctx context.Context, files []domain.TestFile) (<-chan *TargetEvent, <-chan error) {
 	events := make(chan *TargetEvent, 100)
 	errs := make(chan error, 1)
 
 	// Group files by target name
-	grouped := make(map[string][]string)
+	grouped := make(map[string]*targetRun)
 	for _, f := range files {
-		grouped[f.TargetName] = append(grouped[f.TargetName], f.Path)
+		run := grouped[f.TargetName]
+		if run == nil {
+			run = &targetRun{}
+			grouped[f.TargetName] = run
+		}
+		if f.IsSuite() {
+			run.suites = append(run.suites, f.Suite)
+		} else {
+			run.files = append(run.files, f.Path)
+		}
 	}
 
 	var wg sync.WaitGroup
 
-	for targetName, targetFiles := range grouped {
-		target, ok := e.Targets[targetName]
-		if !ok {
-			continue
-		}
+	for targetName, run := range grouped {
+		for _, runName := range e.RunNames(targetName) {
+			target, ok := e.Targets[runName]
+			if !ok {
+				continue
+			}
 
-		wg.Add(1)
-		go func(tName string, tTarget config.Target, tFiles []string) {
-			defer wg.Done()
-			e.runTarget(ctx, tName, tTarget, tFiles, events)
-		}(targetName, target, targetFiles)
+			wg.Add(1)
+			go func(tName string, tTarget config.Target, tRun *targetRun) {
+				defer wg.Done()
+				e.runTarget(ctx, tName, tTarget, tRun, events)
+			}(runName, target, run)
+		}
 	}
 
 	go func() {
@@ -110,19 +262,52 @@ func (e *Executor) Run(ctx context.Context, files []domain.TestFile) (<-chan *Ta
 	return events, errs
 }
 
-// runTarget executes a single target's test command and sends events to the shared channel.
-func (e *Executor) runTarget(ctx context.Context, targetName string, target config.Target, files []string, out chan<- *TargetEvent) {
-	cmdStr := e.BuildCommand(targetName, files)
+// runTarget executes a target's test commands in turn and sends events to
+// the shared channel, followed by a single Done event.
+func (e *Executor) runTarget(ctx context.Context, targetName string, target config.Target, run *targetRun, out chan<- *TargetEvent) {
+	if err := e.ReporterError(targetName); err != nil {
+		out <- &TargetEvent{TargetName: targetName, Done: true, Error: err.Error()}
+		return
+	}
+
+	var cmds []string
+	if len(run.files) > 0 {
+		cmds = append(cmds, e.BuildCommand(targetName, run.files))
+	}
+	for _, suite := range run.suites {
+		cmds = append(cmds, e.BuildSuiteCommand(targetName, suite))
+	}
+
+	var errMsgs []string
+	for _, cmdStr := range cmds {
+		if ctx.Err() != nil {
+			break
+		}
+		if errMsg := e.runCommand(ctx, targetName, target, cmdStr, out); errMsg != "" {
+			errMsgs = append(errMsgs, errMsg)
+		}
+	}
+	out <- &TargetEvent{TargetName: targetName, Done: true, Error: strings.Join(errMsgs, "\n")}
+}
+
+// runCommand executes one test command, forwarding its events. It returns
+// an error message if the command failed without producing test output.
+func (e *Executor) runCommand(ctx context.Context, targetName string, target config.Target, cmdStr string, out chan<- *TargetEvent) string {
 	cmd := exec.CommandContext(ctx, "sh", "-c", cmdStr)
 
 	if target.WorkingDir != "" {
 		cmd.Dir = target.WorkingDir
 	}
+	if len(target.Env) > 0 {
+		cmd.Env = os.Environ()
+		for k, v := range target.Env {
+			cmd.Env = append(cmd.Env, k+"="+v)
+		}
+	}
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		out <- &TargetEvent{TargetName: targetName, Done: true, Error: err.Error()}
-		return
+		return err.Error()
 	}
 
 	// Capture stderr separately so we can report errors
@@ -130,8 +315,7 @@ func (e *Executor) runTarget(ctx context.Context, targetName string, target conf
 	cmd.Stderr = &stderrBuf
 
 	if err := cmd.Start(); err != nil {
-		out <- &TargetEvent{TargetName: targetName, Done: true, Error: err.Error()}
-		return
+		return err.Error()
 	}
 
 	targetEvents := make(chan *parser.Event, 100)
@@ -140,10 +324,12 @@ func (e *Executor) runTarget(ctx context.Context, targetName string, target conf
 		parser.ParseStream(stdout, targetEvents)
 	}()
 
+	pathMap := e.pathMaps[targetName]
 	for ev := range targetEvents {
 		if ev.Type != parser.EventOutput {
 			hasStructuredOutput = true
 		}
+		toHost(pathMap, ev)
 		out <- &TargetEvent{
 			TargetName: targetName,
 			Event:      ev,
@@ -154,14 +340,27 @@ func (e *Executor) runTarget(ctx context.Context, targetName string, target conf
 	// produce non-zero exit codes which is expected
 	waitErr := cmd.Wait()
 
-	doneEvent := &TargetEvent{TargetName: targetName, Done: true}
 	// If no TeamCity output was produced and the command failed, report the error
 	if !hasStructuredOutput && waitErr != nil {
 		errMsg := stderrBuf.String()
 		if errMsg == "" {
 			errMsg = waitErr.Error()
 		}
-		doneEvent.Error = errMsg
+		return pathMap.ToHost(errMsg)
+	}
+	return ""
+}
+
+// toHost translates the runner paths in ev, such as those in stack traces,
+// locationHints and suite names, to host paths.
+func toHost(pathMap config.PathMapper, ev *parser.Event) {
+	if len(pathMap) == 0 {
+		return
+	}
+	ev.Location = pathMap.ToHost(ev.Location)
+	ev.Message = pathMap.ToHost(ev.Message)
+	ev.Details = pathMap.ToHost(ev.Details)
+	if ev.Type == parser.EventSuiteStarted || ev.Type == parser.EventSuiteFinished {
+		ev.Name = pathMap.ToHost(ev.Name)
 	}
-	out <- doneEvent
 }
@@ -1,10 +1,15 @@
 package runner
 
 import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/meijin/lazytest/internal/config"
+	"github.com/meijin/lazytest/internal/domain"
 )
 
 func TestBuildCommandFiles(t *testing.T) {
@@ -82,6 +87,7 @@ func TestBuildCommandEmptyFiles(t *testing.T) {
 }
 
 func TestBuildCommandVitest(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
 	e := NewExecutor(config.Config{
 		Targets: []config.Target{
 			{Name: "vitest", Command: "npx vitest run --reporter={reporter} {files}"},
@@ -89,11 +95,11 @@ func TestBuildCommandVitest(t *testing.T) {
 	})
 
 	cmd := e.BuildCommand("vitest", []string{"src/App.test.ts", "src/Page.test.tsx"})
-	// {reporter} should be replaced with the actual temp file path
+	// {reporter} should be replaced with the installed reporter's path
 	if strings.Contains(cmd, "{reporter}") {
 		t.Errorf("command still contains {reporter} placeholder: %q", cmd)
 	}
-	if !strings.Contains(cmd, "lazytest-vitest-reporter.mjs") {
+	if !strings.Contains(cmd, "/lazytest/reporters/vitest-reporter-") {
 		t.Errorf("command does not contain reporter path: %q", cmd)
 	}
 	if !strings.Contains(cmd, "src/App.test.ts src/Page.test.tsx") {
@@ -102,6 +108,7 @@ func TestBuildCommandVitest(t *testing.T) {
 }
 
 func TestBuildCommandJest(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
 	e := NewExecutor(config.Config{
 		Targets: []config.Target{
 			{Name: "jest", Command: "npx jest --reporters={reporter} -- {files}"},
@@ -109,11 +116,11 @@ func TestBuildCommandJest(t *testing.T) {
 	})
 
 	cmd := e.BuildCommand("jest", []string{"src/App.test.ts", "src/Page.test.tsx"})
-	// {reporter} should be replaced with the actual temp file path
+	// {reporter} should be replaced with the installed reporter's path
 	if strings.Contains(cmd, "{reporter}") {
 		t.Errorf("command still contains {reporter} placeholder: %q", cmd)
 	}
-	if !strings.Contains(cmd, "lazytest-jest-reporter.js") {
+	if !strings.Contains(cmd, "/lazytest/reporters/jest-reporter-") {
 		t.Errorf("command does not contain jest reporter path: %q", cmd)
 	}
 	if !strings.Contains(cmd, "src/App.test.ts src/Page.test.tsx") {
@@ -135,6 +142,7 @@ func TestBuildCommandUnknownTarget(t *testing.T) {
 }
 
 func TestBuildCommandMultipleTargets(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
 	e := NewExecutor(config.Config{
 		Targets: []config.Target{
 			{Name: "phpunit", Command: "phpunit --teamcity {files}"},
@@ -148,10 +156,245 @@ func TestBuildCommandMultipleTargets(t *testing.T) {
 	if phpCmd != "phpunit --teamcity tests/FooTest.php" {
 		t.Errorf("phpunit cmd = %q", phpCmd)
 	}
-	if !strings.Contains(vtCmd, "lazytest-vitest-reporter.mjs") {
+	if !strings.Contains(vtCmd, "/lazytest/reporters/vitest-reporter-") {
 		t.Errorf("vitest cmd missing reporter path: %q", vtCmd)
 	}
 	if !strings.Contains(vtCmd, "src/App.test.ts") {
 		t.Errorf("vitest cmd missing file: %q", vtCmd)
 	}
 }
+
+func TestBuildCommandJestPackageTarget(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	e := NewExecutor(config.Config{
+		Targets: []config.Target{
+			{Name: "jest:web", Command: "npx jest --reporters={reporter} -- {files}"},
+		},
+	})
+
+	cmd := e.BuildCommand("jest:web", []string{"src/App.test.ts"})
+	if !strings.Contains(cmd, "/lazytest/reporters/jest-reporter-") {
+		t.Errorf("command does not contain jest reporter path: %q", cmd)
+	}
+}
+
+func TestBuildCommandReporterByType(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	e := NewExecutor(config.Config{
+		Targets: []config.Target{
+			{Name: "frontend", Type: "jest", Command: "npx jest --reporters={reporter} -- {files}"},
+			{Name: "backend", Type: "phpunit", Command: "phpunit --log {reporter} {files}"},
+		},
+	})
+
+	cmd := e.BuildCommand("frontend", []string{"src/App.test.ts"})
+	if !strings.Contains(cmd, "/lazytest/reporters/jest-reporter-") {
+		t.Errorf("command does not contain jest reporter path: %q", cmd)
+	}
+	if err := e.ReporterError("backend"); err == nil || !strings.Contains(err.Error(), "no built-in reporter for phpunit") {
+		t.Errorf("ReporterError(backend) = %v, want a missing reporter error", err)
+	}
+}
+
+func TestBuildSuiteCommand(t *testing.T) {
+	e := NewExecutor(config.Config{
+		Targets: []config.Target{
+			{Name: "phpunit", Command: "./vendor/bin/phpunit --teamcity {files}"},
+		},
+	})
+
+	cmd := e.BuildSuiteCommand("phpunit", "Bob's Suite")
+	expected := `./vendor/bin/phpunit --teamcity --testsuite 'Bob'\''s Suite'`
+	if cmd != expected {
+		t.Errorf("got %q, want %q", cmd, expected)
+	}
+}
+
+func TestBuildSuiteCommandPlaceholders(t *testing.T) {
+	e := NewExecutor(config.Config{
+		Targets: []config.Target{
+			{Name: "web", Type: "vitest", Command: "npx vitest run --reporter={reporter} {dirs}"},
+		},
+	})
+
+	cmd := e.BuildSuiteCommand("web", "unit")
+	if strings.Contains(cmd, "{") || !strings.Contains(cmd, "/lazytest/reporters/") || !strings.HasSuffix(cmd, " --testsuite 'unit'") {
+		t.Errorf("got %q, want the reporter path and a --testsuite option", cmd)
+	}
+}
+
+func TestBuildCommandDirs(t *testing.T) {
+	e := NewExecutor(config.Config{
+		Targets: []config.Target{
+			{Name: "go", Command: "go test -json {dirs}", WorkingDir: "svc/"},
+		},
+	})
+
+	cmd := e.BuildCommand("go", []string{"svc/a_test.go", "svc/api/b_test.go", "svc/api/c_test.go"})
+	expected := "go test -json ./ ./api"
+	if cmd != expected {
+		t.Errorf("got %q, want %q", cmd, expected)
+	}
+}
+
+func TestBuildCommandNames(t *testing.T) {
+	e := NewExecutor(config.Config{
+		Targets: []config.Target{
+			{Name: "cargo", Command: "cargo test $(printf -- '--test %s ' {names})"},
+		},
+	})
+
+	cmd := e.BuildCommand("cargo", []string{"tests/math.rs", "tests/io.rs"})
+	expected := "cargo test $(printf -- '--test %s ' math io)"
+	if cmd != expected {
+		t.Errorf("got %q, want %q", cmd, expected)
+	}
+}
+
+func TestBuildCommandRSpecReporter(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	e := NewExecutor(config.Config{
+		Targets: []config.Target{
+			{Name: "rspec", Command: "rspec --require {reporter} {files}"},
+		},
+	})
+
+	cmd := e.BuildCommand("rspec", []string{"spec/user_spec.rb"})
+	if !strings.Contains(cmd, "/lazytest/reporters/rspec-reporter-") {
+		t.Errorf("got %q, want the RSpec formatter path", cmd)
+	}
+}
+
+func TestBuildCommandPytestReporter(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	e := NewExecutor(config.Config{
+		Targets: []config.Target{
+			{Name: "pytest", Command: "PYTHONPATH={reporter} python -m pytest -p lazytest_pytest {files}"},
+		},
+	})
+
+	cmd := e.BuildCommand("pytest", []string{"tests/test_a.py"})
+	dir := strings.TrimSuffix(strings.TrimPrefix(cmd, "PYTHONPATH="), " python -m pytest -p lazytest_pytest tests/test_a.py")
+	if _, err := os.Stat(filepath.Join(dir, "lazytest_pytest.py")); err != nil {
+		t.Errorf("plugin not found in %q: %v", dir, err)
+	}
+}
+
+func TestRunFailsTargetWhenReporterCannotBeInstalled(t *testing.T) {
+	// A file where the cache directory should be makes installation fail.
+	cache := filepath.Join(t.TempDir(), "cache")
+	if err := os.WriteFile(cache, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XDG_CACHE_HOME", cache)
+
+	e := NewExecutor(config.Config{
+		Targets: []config.Target{
+			{Name: "vitest", Command: "echo {reporter} {files}"},
+			{Name: "phpunit", Command: "true {files}"},
+		},
+	})
+	if e.ReporterError("vitest") == nil {
+		t.Fatal("ReporterError(vitest) = nil, want an error")
+	}
+	if err := e.ReporterError("phpunit"); err != nil {
+		t.Errorf("ReporterError(phpunit) = %v, want nil", err)
+	}
+
+	events, _ := e.Run(context.Background(), []domain.TestFile{
+		{Path: "src/a.test.ts", TargetName: "vitest"},
+		{Path: "tests/ATest.php", TargetName: "phpunit"},
+	})
+	errs := make(map[string]string)
+	for ev := range events {
+		if ev.Done {
+			errs[ev.TargetName] = ev.Error
+		}
+	}
+	if !strings.Contains(errs["vitest"], "installing vitest reporter") {
+		t.Errorf("vitest error = %q, want the install error", errs["vitest"])
+	}
+	if errs["phpunit"] != "" {
+		t.Errorf("phpunit error = %q, want none", errs["phpunit"])
+	}
+}
+
+func TestRunMatrixVariants(t *testing.T) {
+	e := NewExecutor(config.Config{
+		Targets: []config.Target{{
+			Name:    "phpunit",
+			Command: "true {files}; echo \"##teamcity[testSuiteStarted name='$PHP']\"",
+			Matrix: []config.Variant{
+				{Name: "php8.2", Env: map[string]string{"PHP": "8.2"}},
+				{Name: "php8.3", Env: map[string]string{"PHP": "8.3"}},
+			},
+		}},
+	})
+	if got := e.RunNames("phpunit"); !reflect.DeepEqual(got, []string{"phpunit[php8.2]", "phpunit[php8.3]"}) {
+		t.Fatalf("RunNames = %v", got)
+	}
+
+	events, _ := e.Run(context.Background(), []domain.TestFile{{Path: "tests/ATest.php", TargetName: "phpunit"}})
+	suites := make(map[string]string)
+	done := 0
+	for ev := range events {
+		if ev.Done {
+			done++
+		} else if ev.Event != nil && ev.Event.Name != "" {
+			suites[ev.TargetName] = ev.Event.Name
+		}
+	}
+	if done != 2 {
+		t.Errorf("Done events = %d, want one per variant", done)
+	}
+	want := map[string]string{"phpunit[php8.2]": "8.2", "phpunit[php8.3]": "8.3"}
+	if !reflect.DeepEqual(suites, want) {
+		t.Errorf("suites = %v, want %v", suites, want)
+	}
+}
+
+func TestBuildCommandPathMap(t *testing.T) {
+	e := NewExecutor(config.Config{
+		Targets: []config.Target{{
+			Name:            "phpunit",
+			Command:         "docker compose exec app phpunit --teamcity {files}",
+			PathStripPrefix: "server/",
+			PathMap:         []config.PathRule{{Host: "server/src/", Runner: "/var/www/html/"}},
+		}},
+	})
+
+	cmd := e.BuildCommand("phpunit", []string{"server/src/tests/FooTest.php", "server/other/BarTest.php"})
+	expected := "docker compose exec app phpunit --teamcity /var/www/html/tests/FooTest.php other/BarTest.php"
+	if cmd != expected {
+		t.Errorf("got %q, want %q", cmd, expected)
+	}
+}
+
+func TestRunMapsOutputPathsToHost(t *testing.T) {
+	e := NewExecutor(config.Config{
+		Targets: []config.Target{{
+			Name: "phpunit",
+			Command: `true {files}; ` +
+				`echo "##teamcity[testSuiteStarted name='/var/www/html/tests/FooTest.php' locationHint='php_qn:///var/www/html/tests/FooTest.php::\\Tests\\FooTest']"; ` +
+				`echo "##teamcity[testStarted name='testA' locationHint='php_qn:///var/www/html/tests/FooTest.php::\\Tests\\FooTest::testA']"; ` +
+				`echo "##teamcity[testFailed name='testA' message='failed' details='/var/www/html/tests/FooTest.php:12']"`,
+			PathMap: []config.PathRule{{Host: "server/src/", Runner: "/var/www/html/"}},
+		}},
+	})
+
+	events, _ := e.Run(context.Background(), []domain.TestFile{{Path: "server/src/tests/FooTest.php", TargetName: "phpunit"}})
+	var got []string
+	for ev := range events {
+		if ev.Event != nil {
+			got = append(got, ev.Event.Name, ev.Event.Location, ev.Event.Details)
+		}
+	}
+	want := []string{
+		"server/src/tests/FooTest.php", `php_qn://server/src/tests/FooTest.php::\Tests\FooTest`, "",
+		"testA", `php_qn://server/src/tests/FooTest.php::\Tests\FooTest::testA`, "",
+		"testA", "", "server/src/tests/FooTest.php:12",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,40 @@
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/meijin/lazytest/internal/domain"
+)
+
+const setsFileName = "sets.json"
+
+// LoadSets reads the test sets saved in dir, sorted by name. A missing file
+// yields no sets.
+func LoadSets(dir string) ([]domain.TestSet, error) {
+	data, err := os.ReadFile(filepath.Join(dir, setsFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var sets []domain.TestSet
+	if err := json.Unmarshal(data, &sets); err != nil {
+		return nil, err
+	}
+	sort.Slice(sets, func(i, j int) bool { return sets[i].Name < sets[j].Name })
+	return sets, nil
+}
+
+// SaveSets writes sets into dir, replacing the saved ones.
+func SaveSets(dir string, sets []domain.TestSet) error {
+	data, err := json.MarshalIndent(sets, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(filepath.Join(dir, setsFileName), data)
+}
@@ -0,0 +1,33 @@
+package state
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/meijin/lazytest/internal/domain"
+)
+
+func TestSetsRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+
+	if sets, err := LoadSets(dir); err != nil || sets != nil {
+		t.Fatalf("LoadSets on empty dir = %v, %v; want no sets", sets, err)
+	}
+
+	sets := []domain.TestSet{
+		{Name: "smoke", Query: "@backend smoke"},
+		{Name: "checkout-flow", Query: "checkout", Files: []string{"api:tests/CheckoutTest.php", "web:testsuite:Unit"}},
+	}
+	if err := SaveSets(dir, sets); err != nil {
+		t.Fatalf("SaveSets error: %v", err)
+	}
+
+	got, err := LoadSets(dir)
+	if err != nil {
+		t.Fatalf("LoadSets error: %v", err)
+	}
+	want := []domain.TestSet{sets[1], sets[0]}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadSets = %+v, want %+v", got, want)
+	}
+}
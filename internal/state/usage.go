@@ -0,0 +1,132 @@
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/meijin/lazytest/internal/domain"
+)
+
+const usageFileName = "usage.json"
+
+// maxUsageEntries bounds the usage file; the least recently used entries are
+// dropped first.
+const maxUsageEntries = 2000
+
+// UsageEntry records how often and how recently a test file was run.
+type UsageEntry struct {
+	Target     string            `json:"target"`
+	Path       string            `json:"path"`
+	Count      int               `json:"count"`
+	LastUsed   time.Time         `json:"last_used"`
+	LastStatus domain.TestStatus `json:"last_status"`
+}
+
+// Usage tracks file run history for frecency ranking.
+type Usage struct {
+	entries map[string]*UsageEntry
+}
+
+// NewUsage returns an empty Usage.
+func NewUsage() *Usage {
+	return &Usage{entries: make(map[string]*UsageEntry)}
+}
+
+func usageKey(target, path string) string {
+	return target + "\x00" + path
+}
+
+// LoadUsage reads usage history from dir. A missing file yields empty usage.
+func LoadUsage(dir string) (*Usage, error) {
+	u := NewUsage()
+	data, err := os.ReadFile(filepath.Join(dir, usageFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return u, nil
+		}
+		return u, err
+	}
+
+	var entries []*UsageEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return u, err
+	}
+	for _, e := range entries {
+		u.entries[usageKey(e.Target, e.Path)] = e
+	}
+	return u, nil
+}
+
+// Marshal serializes the usage history, most recently used first.
+func (u *Usage) Marshal() ([]byte, error) {
+	entries := make([]*UsageEntry, 0, len(u.entries))
+	for _, e := range u.entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].LastUsed.After(entries[j].LastUsed)
+	})
+	if len(entries) > maxUsageEntries {
+		entries = entries[:maxUsageEntries]
+	}
+	return json.MarshalIndent(entries, "", "  ")
+}
+
+// SaveUsage writes previously marshaled usage data into dir.
+func SaveUsage(dir string, data []byte) error {
+	return WriteFileAtomic(filepath.Join(dir, usageFileName), data)
+}
+
+// RecordRun notes that the file was run at now.
+func (u *Usage) RecordRun(f domain.TestFile, now time.Time) {
+	k := usageKey(f.TargetName, f.Path)
+	e, ok := u.entries[k]
+	if !ok {
+		e = &UsageEntry{Target: f.TargetName, Path: f.Path}
+		u.entries[k] = e
+	}
+	e.Count++
+	e.LastUsed = now
+}
+
+// RecordStatus stores the outcome of the file's most recent run.
+func (u *Usage) RecordStatus(f domain.TestFile, status domain.TestStatus) {
+	if e, ok := u.entries[usageKey(f.TargetName, f.Path)]; ok {
+		e.LastStatus = status
+	}
+}
+
+// Score returns the frecency of the file at now: run count weighted by how
+// recently it was last run, with a bonus for files that last failed.
+// Files that were never run score 0.
+func (u *Usage) Score(f domain.TestFile, now time.Time) int {
+	e, ok := u.entries[usageKey(f.TargetName, f.Path)]
+	if !ok || e.Count == 0 {
+		return 0
+	}
+
+	age := now.Sub(e.LastUsed)
+	var weight int
+	switch {
+	case age < 4*time.Hour:
+		weight = 100
+	case age < 24*time.Hour:
+		weight = 70
+	case age < 7*24*time.Hour:
+		weight = 50
+	case age < 30*24*time.Hour:
+		weight = 30
+	default:
+		weight = 10
+	}
+
+	count := min(e.Count, 20)
+	score := weight * (10 + count) / 10
+	if e.LastStatus == domain.StatusFailed {
+		score += weight / 2
+	}
+	return score
+}
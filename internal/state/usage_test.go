@@ -0,0 +1,107 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/meijin/lazytest/internal/domain"
+)
+
+func TestUsageScoreNeverRun(t *testing.T) {
+	u := NewUsage()
+	f := domain.TestFile{Path: "tests/FooTest.php", TargetName: "phpunit"}
+	if got := u.Score(f, time.Now()); got != 0 {
+		t.Errorf("Score = %d, want 0", got)
+	}
+}
+
+func TestUsageScoreRecencyAndFrequency(t *testing.T) {
+	now := time.Now()
+	u := NewUsage()
+	recent := domain.TestFile{Path: "tests/RecentTest.php", TargetName: "phpunit"}
+	old := domain.TestFile{Path: "tests/OldTest.php", TargetName: "phpunit"}
+	frequent := domain.TestFile{Path: "tests/FrequentTest.php", TargetName: "phpunit"}
+
+	u.RecordRun(recent, now)
+	u.RecordRun(old, now.Add(-10*24*time.Hour))
+	for i := 0; i < 5; i++ {
+		u.RecordRun(frequent, now)
+	}
+
+	if u.Score(recent, now) <= u.Score(old, now) {
+		t.Errorf("recent score %d should exceed old score %d", u.Score(recent, now), u.Score(old, now))
+	}
+	if u.Score(frequent, now) <= u.Score(recent, now) {
+		t.Errorf("frequent score %d should exceed recent score %d", u.Score(frequent, now), u.Score(recent, now))
+	}
+}
+
+func TestUsageScoreFailedBonus(t *testing.T) {
+	now := time.Now()
+	u := NewUsage()
+	passed := domain.TestFile{Path: "a.test.ts", TargetName: "vitest"}
+	failed := domain.TestFile{Path: "b.test.ts", TargetName: "vitest"}
+	u.RecordRun(passed, now)
+	u.RecordRun(failed, now)
+	u.RecordStatus(passed, domain.StatusPassed)
+	u.RecordStatus(failed, domain.StatusFailed)
+
+	if u.Score(failed, now) <= u.Score(passed, now) {
+		t.Errorf("failed score %d should exceed passed score %d", u.Score(failed, now), u.Score(passed, now))
+	}
+}
+
+func TestUsageSaveLoadRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	u := NewUsage()
+	f := domain.TestFile{Path: "src/App.test.ts", TargetName: "vitest"}
+	u.RecordRun(f, now)
+	u.RecordRun(f, now)
+	u.RecordStatus(f, domain.StatusFailed)
+
+	data, err := u.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	if err := SaveUsage(dir, data); err != nil {
+		t.Fatalf("SaveUsage error: %v", err)
+	}
+
+	loaded, err := LoadUsage(dir)
+	if err != nil {
+		t.Fatalf("LoadUsage error: %v", err)
+	}
+	if got, want := loaded.Score(f, now), u.Score(f, now); got != want {
+		t.Errorf("loaded Score = %d, want %d", got, want)
+	}
+}
+
+func TestLoadUsageMissingFile(t *testing.T) {
+	u, err := LoadUsage(t.TempDir())
+	if err != nil {
+		t.Fatalf("LoadUsage error: %v", err)
+	}
+	if u == nil {
+		t.Fatal("expected empty usage, got nil")
+	}
+}
+
+func TestDirUsesXDGStateHome(t *testing.T) {
+	base := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", base)
+
+	dir, err := Dir(t.TempDir())
+	if err != nil {
+		t.Fatalf("Dir error: %v", err)
+	}
+	rel, err := filepath.Rel(base, dir)
+	if err != nil || filepath.Dir(filepath.Dir(rel)) != "lazytest" {
+		t.Errorf("Dir = %q, want under %q/lazytest/projects", dir, base)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		t.Errorf("state dir %q was not created", dir)
+	}
+}
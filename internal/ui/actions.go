@@ -0,0 +1,98 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/meijin/lazytest/internal/config"
+	"github.com/meijin/lazytest/internal/domain"
+	"github.com/meijin/lazytest/internal/runner"
+)
+
+// actionBinding is a configured action with the key binding that runs it
+// in results mode.
+type actionBinding struct {
+	config.Action
+	binding key.Binding
+}
+
+// newActionBindings binds each action to its key. Keys of the built-in
+// results bindings win, so the actions they hide are left out, and don't
+// show in the help bar.
+func newActionBindings(actions []config.Action) []actionBinding {
+	builtin := make(map[string]bool)
+	for _, nb := range resultsKeys.named() {
+		for _, k := range nb.binding.Keys() {
+			builtin[k] = true
+		}
+	}
+	bindings := make([]actionBinding, 0, len(actions))
+	for _, a := range actions {
+		b := key.NewBinding(key.WithHelp("", a.Label))
+		rebind(&b, config.KeyList{a.Key})
+		if builtin[b.Keys()[0]] {
+			continue
+		}
+		bindings = append(bindings, actionBinding{Action: a, binding: b})
+	}
+	return bindings
+}
+
+// matchAction returns the action bound to msg.
+func (a *App) matchAction(msg tea.KeyMsg) (config.Action, bool) {
+	for _, ab := range a.actions {
+		if key.Matches(msg, ab.binding) {
+			return ab.Action, true
+		}
+	}
+	return config.Action{}, false
+}
+
+// runAction runs act on the files of its scope like a test run, with the
+// action's command in place of the targets' commands. It does nothing when
+// the scope has no files the action applies to.
+func (a *App) runAction(act config.Action) tea.Cmd {
+	command := act.Command
+	var files []domain.TestFile
+	switch act.Scope {
+	case "file":
+		if f, ok := a.resolveSelectedFile(); ok {
+			files = []domain.TestFile{f}
+		}
+	case "test":
+		tc := a.results.SelectedTest()
+		f, ok := a.resolveSelectedFile()
+		if tc == nil || !ok {
+			return nil
+		}
+		files = []domain.TestFile{f}
+		command = strings.ReplaceAll(command, "{test}", runner.ShellQuote(tc.Name))
+	case "target":
+		if item := a.results.SelectedItem(); item != nil {
+			targetName, _ := domain.SplitRunName(item.targetName)
+			for _, f := range a.search.AllFiles() {
+				if f.TargetName == targetName && !f.IsSuite() {
+					files = append(files, f)
+				}
+			}
+		}
+	default:
+		files = a.lastFiles
+	}
+
+	targets := make(map[string]config.Target, len(a.config.Targets))
+	for _, t := range a.config.Targets {
+		targets[t.Name] = t
+	}
+	var applicable []domain.TestFile
+	for _, f := range files {
+		if t, ok := targets[f.TargetName]; ok && act.AppliesTo(t) {
+			applicable = append(applicable, f)
+		}
+	}
+	if len(applicable) == 0 {
+		return nil
+	}
+	return a.startRun(runner.NewExecutor(a.config.WithCommand(command)), applicable, act.Label)
+}
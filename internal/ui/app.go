@@ -2,17 +2,21 @@ package ui
 
 import (
 	"context"
+	"fmt"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/meijin/lazytest/internal/config"
+	"github.com/meijin/lazytest/internal/discovery"
 	"github.com/meijin/lazytest/internal/domain"
 	"github.com/meijin/lazytest/internal/runner"
+	"github.com/meijin/lazytest/internal/state"
 )
 
 // Mode represents the current UI mode.
@@ -22,6 +26,8 @@ const (
 	ModeSearch Mode = iota
 	ModeRunning
 	ModeResults
+	ModeSets    // picking a test set
+	ModeSaveSet // naming a test set to save
 )
 
 // Messages
@@ -38,38 +44,125 @@ type testDoneMsg struct {
 	err   error
 }
 
+// stateSavedMsg reports the result of writing state, such as the usage
+// history, in the background.
+type stateSavedMsg struct {
+	err error
+}
+
+type filesScannedMsg struct {
+	files []domain.TestFile
+	err   error
+}
+
 // App is the root bubbletea model.
 type App struct {
 	mode      Mode
 	search    SearchModel
 	running   RunningModel
 	results   ResultsModel
+	sets      SetsModel
 	executor  *runner.Executor
 	config    config.Config
+	actions   []actionBinding
 	lastRun   *domain.AggregatedRun
 	lastFiles []domain.TestFile
+	runLabel  string // the action behind the current results, if any
 	cancel    context.CancelFunc
 	runID     uint64 // incremented on each new test execution
+	usage     *state.Usage
+	stateDir  string // per-project state directory; empty disables persistence
+	scanning  bool   // a rescan is in progress
+	startScan bool   // rescan in the background on Init
 	width     int
 	height    int
 	err       error
 }
 
-func NewApp(cfg config.Config, files []domain.TestFile) App {
-	return App{
+// NewApp creates the root model. stateDir is where run history is persisted
+// for frecency ranking and saved test sets are kept; pass "" to keep them in
+// memory only.
+func NewApp(cfg config.Config, files []domain.TestFile, stateDir string) App {
+	usage := state.NewUsage()
+	if stateDir != "" {
+		if loaded, err := state.LoadUsage(stateDir); err == nil {
+			usage = loaded
+		}
+	}
+
+	// Saved sets are best-effort like usage history.
+	var saved []domain.TestSet
+	if stateDir != "" {
+		saved, _ = state.LoadSets(stateDir)
+	}
+
+	a := App{
 		mode:     ModeSearch,
 		search:   NewSearchModel(files),
 		running:  NewRunningModel(),
 		results:  NewResultsModel(),
+		sets:     NewSetsModel(cfg.Sets, saved),
 		executor: runner.NewExecutor(cfg),
 		config:   cfg,
+		actions:  newActionBindings(cfg.Actions),
+		usage:    usage,
+		stateDir: stateDir,
+	}
+	a.refreshFrecency()
+	return a
+}
+
+// Configure sets up the look and keys of the UI: the theme and icons of
+// opts, then the key bindings and style overrides of the user config. Call
+// it before creating the app.
+func Configure(opts config.Options, u config.UserConfig) {
+	applyTheme(opts.Theme)
+	applyIcons(opts.Icons)
+	applyKeys(u.Keys)
+	applyStyles(u.Styles)
+}
+
+// WithLaunchDir narrows the initial file list to the files under dir (a
+// path relative to the project root) by prefilling the query with a path
+// prefix, for when lazytest was started in a subdirectory. It does nothing
+// if no file lives there.
+func (a App) WithLaunchDir(dir string) App {
+	prefix := strings.TrimSuffix(filepath.ToSlash(dir), "/") + "/"
+	if prefix == "./" || strings.ContainsAny(prefix, " \t") {
+		return a
+	}
+	for _, f := range a.search.AllFiles() {
+		if strings.HasPrefix(f.Path, prefix) {
+			a.search.SetQuery("^" + prefix)
+			return a
+		}
 	}
+	return a
+}
+
+// WithStartupRescan makes the app refresh its file list in the background as
+// soon as it starts, for when the initial files came from a cached index.
+func (a App) WithStartupRescan() App {
+	a.startScan = true
+	a.scanning = true
+	return a
 }
 
 func (a App) Init() tea.Cmd {
+	if a.startScan {
+		return tea.Batch(a.search.input.Focus(), rescanCmd(a.stateDir, a.config.Targets))
+	}
 	return a.search.input.Focus()
 }
 
+// rescanCmd rediscovers test files for all targets and refreshes the index.
+func rescanCmd(stateDir string, targets []config.Target) tea.Cmd {
+	return func() tea.Msg {
+		files, err := discovery.ScanAndIndex(stateDir, targets)
+		return filesScannedMsg{files: files, err: err}
+	}
+}
+
 func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
@@ -90,9 +183,9 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				run := a.running.BuildAggregatedRun(a.lastFiles)
 				a.lastRun = run
 				a.results.SetRun(run)
-				a.updateFileStatuses(run)
+				saveCmd := a.updateFileStatuses(run)
 				a.mode = ModeResults
-				return a, drainEvents(msg.events, msg.errs)
+				return a, tea.Batch(drainEvents(msg.events, msg.errs), saveCmd)
 			}
 		}
 		return a, waitForEvent(a.runID, msg.events, msg.errs)
@@ -106,11 +199,37 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			run := a.running.BuildAggregatedRun(a.lastFiles)
 			a.lastRun = run
 			a.results.SetRun(run)
-			a.updateFileStatuses(run)
+			saveCmd := a.updateFileStatuses(run)
 			a.mode = ModeResults
+			return a, saveCmd
 		}
 		return a, nil
 
+	case stateSavedMsg:
+		if msg.err != nil {
+			a.err = msg.err
+		}
+		return a, nil
+
+	case filesScannedMsg:
+		a.scanning = false
+		if msg.err != nil {
+			a.err = msg.err
+			return a, nil
+		}
+		a.search.SetFiles(msg.files)
+		a.refreshFrecency()
+		return a, nil
+
+	case editorClosedMsg:
+		a.err = msg.err
+		return a, nil
+
+	case filterResultMsg:
+		var cmd tea.Cmd
+		a.search, cmd = a.search.Update(msg)
+		return a, cmd
+
 	case tea.KeyMsg:
 		return a.handleKey(msg)
 	}
@@ -125,12 +244,18 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		var cmd tea.Cmd
 		a.results, cmd = a.results.Update(msg)
 		return a, cmd
+	case ModeSaveSet:
+		var cmd tea.Cmd
+		a.sets, cmd = a.sets.Update(msg)
+		return a, cmd
 	}
 
 	return a, nil
 }
 
-func (a *App) updateFileStatuses(run *domain.AggregatedRun) {
+// updateFileStatuses records per-file outcomes of run and returns a command
+// that persists the updated usage history.
+func (a *App) updateFileStatuses(run *domain.AggregatedRun) tea.Cmd {
 	statusMap := make(map[string]domain.TestStatus)
 
 	// Default all tested files to passed
@@ -138,11 +263,12 @@ func (a *App) updateFileStatuses(run *domain.AggregatedRun) {
 		statusMap[f.Path] = domain.StatusPassed
 	}
 
-	// Mark files as failed if their target has failures
+	// Mark files as failed if their target, or a variant of it, has failures
 	for _, r := range run.Runs {
 		if r.Failed > 0 {
+			base, _ := domain.SplitRunName(r.TargetName)
 			for _, f := range a.lastFiles {
-				if f.TargetName == r.TargetName {
+				if f.TargetName == base {
 					statusMap[f.Path] = domain.StatusFailed
 				}
 			}
@@ -150,6 +276,56 @@ func (a *App) updateFileStatuses(run *domain.AggregatedRun) {
 	}
 
 	a.search.UpdatePrevStatus(statusMap)
+
+	for _, f := range a.lastFiles {
+		a.usage.RecordStatus(f, statusMap[f.Path])
+	}
+	a.refreshFrecency()
+	return a.saveUsageCmd()
+}
+
+// refreshFrecency recomputes usage scores for all files in the search list.
+func (a *App) refreshFrecency() {
+	now := time.Now()
+	frecency := make(map[string]int)
+	for _, f := range a.search.allFiles {
+		if score := a.usage.Score(f, now); score > 0 {
+			frecency[fileKey(f)] = score
+		}
+	}
+	a.search.SetFrecency(frecency)
+}
+
+// saveUsageCmd snapshots the usage history and writes it in the background.
+func (a *App) saveUsageCmd() tea.Cmd {
+	if a.stateDir == "" {
+		return nil
+	}
+	data, err := a.usage.Marshal()
+	if err != nil {
+		return func() tea.Msg { return stateSavedMsg{fmt.Errorf("saving run history: %w", err)} }
+	}
+	dir := a.stateDir
+	return func() tea.Msg {
+		if err := state.SaveUsage(dir, data); err != nil {
+			return stateSavedMsg{fmt.Errorf("saving run history: %w", err)}
+		}
+		return nil
+	}
+}
+
+// saveSetsCmd writes the saved test sets in the background.
+func (a *App) saveSetsCmd() tea.Cmd {
+	if a.stateDir == "" {
+		return nil
+	}
+	dir, sets := a.stateDir, append([]domain.TestSet(nil), a.sets.Saved()...)
+	return func() tea.Msg {
+		if err := state.SaveSets(dir, sets); err != nil {
+			return stateSavedMsg{fmt.Errorf("saving test sets: %w", err)}
+		}
+		return nil
+	}
 }
 
 // cancelRun cancels the current test execution and bumps the runID.
@@ -173,11 +349,72 @@ func (a App) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				return a, a.startTests(files)
 			}
 			return a, nil
+		case key.Matches(msg, searchKeys.Rescan):
+			if a.scanning {
+				return a, nil
+			}
+			a.scanning = true
+			return a, rescanCmd(a.stateDir, a.config.Targets)
+		case key.Matches(msg, searchKeys.SaveSet):
+			set := domain.TestSet{Query: a.search.Query(), Files: a.search.SelectedEntries()}
+			if len(set.Files) == 0 && strings.TrimSpace(set.Query) == "" {
+				return a, nil
+			}
+			a.mode = ModeSaveSet
+			a.search.input.Blur()
+			return a, a.sets.StartNaming(set)
+		case key.Matches(msg, searchKeys.Sets):
+			a.mode = ModeSets
+			a.search.input.Blur()
+			a.sets.StartPicking()
+			return a, nil
 		}
 		var cmd tea.Cmd
 		a.search, cmd = a.search.Update(msg)
 		return a, cmd
 
+	case ModeSaveSet:
+		switch {
+		case key.Matches(msg, setsKeys.Quit):
+			return a, tea.Quit
+		case key.Matches(msg, setsKeys.Close):
+			a.mode = ModeSearch
+			return a, a.search.input.Focus()
+		case key.Matches(msg, setsKeys.Save):
+			if !a.sets.Confirm() {
+				return a, nil
+			}
+			a.mode = ModeSearch
+			return a, tea.Batch(a.search.input.Focus(), a.saveSetsCmd())
+		}
+		var cmd tea.Cmd
+		a.sets, cmd = a.sets.Update(msg)
+		return a, cmd
+
+	case ModeSets:
+		switch {
+		case key.Matches(msg, setsKeys.Quit):
+			return a, tea.Quit
+		case key.Matches(msg, setsKeys.Close):
+			a.mode = ModeSearch
+			return a, a.search.input.Focus()
+		case key.Matches(msg, setsKeys.Load):
+			if set, ok := a.sets.Selected(); ok {
+				a.search.ApplySet(set)
+				a.mode = ModeSearch
+				return a, a.search.input.Focus()
+			}
+			return a, nil
+		case key.Matches(msg, setsKeys.Delete):
+			if a.sets.DeleteSelected() {
+				return a, a.saveSetsCmd()
+			}
+			return a, nil
+		}
+		var cmd tea.Cmd
+		a.sets, cmd = a.sets.Update(msg)
+		return a, cmd
+
 	case ModeRunning:
 		switch {
 		case key.Matches(msg, runningKeys.Cancel):
@@ -185,7 +422,7 @@ func (a App) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			a.mode = ModeSearch
 			a.search.input.Focus()
 			return a, nil
-		case key.Matches(msg, searchKeys.Quit):
+		case key.Matches(msg, runningKeys.Quit):
 			a.cancelRun()
 			return a, tea.Quit
 		}
@@ -216,11 +453,14 @@ func (a App) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 			return a, nil
 		case key.Matches(msg, resultsKeys.Open):
-			if filePath := a.resolveSelectedFile(); filePath != "" {
-				return a, openFileCmd(filePath)
+			if f, ok := a.resolveSelectedFile(); ok {
+				return a, openFileCmd(a.config.Editor, f.Path)
 			}
 			return a, nil
 		}
+		if act, ok := a.matchAction(msg); ok {
+			return a, a.runAction(act)
+		}
 		var cmd tea.Cmd
 		a.results, cmd = a.results.Update(msg)
 		return a, cmd
@@ -230,16 +470,27 @@ func (a App) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 }
 
 func (a *App) startTests(files []domain.TestFile) tea.Cmd {
+	return a.startRun(a.executor, files, "")
+}
+
+// startRun runs files with executor and switches to running mode. label
+// names the action being run, if any.
+func (a *App) startRun(executor *runner.Executor, files []domain.TestFile, label string) tea.Cmd {
 	a.cancelRun()
 
 	a.lastFiles = files
-	a.running.Reset(files)
+	a.runLabel = label
+	now := time.Now()
+	for _, f := range files {
+		a.usage.RecordRun(f, now)
+	}
+	a.running.Reset(files, executor.RunNames)
 	a.mode = ModeRunning
 
 	ctx, cancel := context.WithCancel(context.Background())
 	a.cancel = cancel
 
-	events, errs := a.executor.Run(ctx, files)
+	events, errs := executor.Run(ctx, files)
 
 	return waitForEvent(a.runID, events, errs)
 }
@@ -265,35 +516,52 @@ func drainEvents(events <-chan *runner.TargetEvent, errs <-chan error) tea.Cmd {
 }
 
 // resolveSelectedFile maps the currently selected suite/test in results
-// back to a file path from lastFiles.
-func (a *App) resolveSelectedFile() string {
+// back to a file from lastFiles.
+func (a *App) resolveSelectedFile() (domain.TestFile, bool) {
 	item := a.results.SelectedItem()
 	if item == nil {
-		return ""
+		return domain.TestFile{}, false
 	}
 
-	targetName := item.targetName
+	targetName, _ := domain.SplitRunName(item.targetName)
 
-	// Collect files for this target
+	// Collect files for this target; suite entries have no file to open
 	var targetFiles []domain.TestFile
 	for _, f := range a.lastFiles {
-		if f.TargetName == targetName {
+		if f.TargetName == targetName && !f.IsSuite() {
 			targetFiles = append(targetFiles, f)
 		}
 	}
 
 	if len(targetFiles) == 0 {
-		return ""
+		return domain.TestFile{}, false
 	}
 
 	// If only one file was tested for this target, always return it
 	if len(targetFiles) == 1 {
-		return targetFiles[0].Path
+		return targetFiles[0], true
 	}
 
 	suite := a.results.SelectedSuite()
 	if suite == nil {
-		return targetFiles[0].Path
+		return targetFiles[0], true
+	}
+
+	// Strategy 0: the location the runner reported for the test or suite
+	locations := []string{suite.Location}
+	if tc := a.results.SelectedTest(); tc != nil {
+		locations = append([]string{tc.Location}, locations...)
+	}
+	for _, loc := range locations {
+		p := locationPath(loc)
+		if p == "" {
+			continue
+		}
+		for _, f := range targetFiles {
+			if p == f.Path || strings.HasSuffix(p, "/"+f.Path) {
+				return f, true
+			}
+		}
 	}
 
 	suiteName := strings.ReplaceAll(suite.Name, `\`, "/")
@@ -302,7 +570,7 @@ func (a *App) resolveSelectedFile() string {
 	// Strategy 1: File path contains suite name (handles working_dir prefix)
 	for _, f := range targetFiles {
 		if strings.Contains(strings.ToLower(f.Path), suiteNameLower) {
-			return f.Path
+			return f, true
 		}
 	}
 
@@ -311,7 +579,7 @@ func (a *App) resolveSelectedFile() string {
 	for _, f := range targetFiles {
 		fNoExt := stripExtensions(strings.ToLower(f.Path))
 		if strings.HasSuffix(fNoExt, suiteNoExt) {
-			return f.Path
+			return f, true
 		}
 	}
 
@@ -323,11 +591,29 @@ func (a *App) resolveSelectedFile() string {
 		segments := strings.Split(fLower, "/")
 		lastSeg := stripExtensions(segments[len(segments)-1])
 		if lastSeg == className {
-			return f.Path
+			return f, true
 		}
 	}
 
-	return targetFiles[0].Path
+	return targetFiles[0], true
+}
+
+// locationPath returns the file path of a runner location such as
+// "file:///app/a.test.ts:12" or "php_qn:///app/tests/FooTest.php::\Tests\FooTest".
+func locationPath(loc string) string {
+	if _, rest, ok := strings.Cut(loc, "://"); ok {
+		loc = rest
+	}
+	loc, _, _ = strings.Cut(loc, "::")
+	// Drop a trailing :line or :line:column.
+	for i := 0; i < 2; i++ {
+		j := strings.LastIndexByte(loc, ':')
+		if j < 0 || strings.Trim(loc[j+1:], "0123456789") != "" || j == len(loc)-1 {
+			break
+		}
+		loc = loc[:j]
+	}
+	return strings.TrimPrefix(loc, "./")
 }
 
 // stripExtensions removes file extensions (e.g. ".test.php" → "", "ExampleTest.php" → "ExampleTest").
@@ -341,7 +627,25 @@ func stripExtensions(name string) string {
 	}
 }
 
-func openFileCmd(filePath string) tea.Cmd {
+type editorClosedMsg struct {
+	err error
+}
+
+// openFileCmd opens filePath with the configured editor command, handing it
+// the terminal so terminal editors work too, or with the system's opener
+// when no editor is configured.
+func openFileCmd(editor, filePath string) tea.Cmd {
+	if editor != "" {
+		cmdStr := editor
+		if strings.Contains(cmdStr, "{file}") {
+			cmdStr = strings.ReplaceAll(cmdStr, "{file}", runner.ShellQuote(filePath))
+		} else {
+			cmdStr += " " + runner.ShellQuote(filePath)
+		}
+		return tea.ExecProcess(exec.Command("sh", "-c", cmdStr), func(err error) tea.Msg {
+			return editorClosedMsg{err: err}
+		})
+	}
 	return func() tea.Msg {
 		var cmd *exec.Cmd
 		switch runtime.GOOS {
@@ -367,10 +671,22 @@ func (a App) View() string {
 		titleBar = titleStyle.Render("Test Results")
 	} else if a.mode == ModeRunning {
 		titleBar = titleStyle.Render("Running Tests")
+		if a.runLabel != "" {
+			titleBar = titleStyle.Render("Running: " + a.runLabel)
+		}
+	} else if a.mode == ModeSets || a.mode == ModeSaveSet {
+		titleBar = titleStyle.Render("Test Sets")
 	}
 
 	statusBar := renderStatusBar(a.lastRun, a.width-2)
-	helpBar := renderHelpBar(a.mode, a.width-2)
+	var extraKeys []key.Binding
+	if a.results.HasMatrix() {
+		extraKeys = append(extraKeys, resultsKeys.Matrix)
+	}
+	for _, ab := range a.actions {
+		extraKeys = append(extraKeys, ab.binding)
+	}
+	helpBar := renderHelpBar(a.mode, extraKeys, a.width-2)
 
 	chrome := lipgloss.Height(titleBar) + lipgloss.Height(statusBar) + lipgloss.Height(helpBar) + 2
 	contentHeight := a.height - chrome
@@ -387,6 +703,8 @@ func (a App) View() string {
 		content = a.running.View(contentWidth, contentHeight)
 	case ModeResults:
 		content = a.results.View(contentWidth, contentHeight)
+	case ModeSets, ModeSaveSet:
+		content = a.sets.View(contentWidth, contentHeight)
 	}
 
 	body := lipgloss.JoinVertical(lipgloss.Left,
@@ -0,0 +1,183 @@
+package ui
+
+import (
+	"container/heap"
+	"context"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/meijin/lazytest/internal/domain"
+)
+
+const (
+	// rankLimit is how many of the best matches are fully ordered by score.
+	// Matches beyond it keep their original order; nobody scrolls that far.
+	rankLimit = 1000
+
+	// syncFilterLimit is the file count up to which filtering runs inline in
+	// Update. Larger lists are filtered off the UI goroutine.
+	syncFilterLimit = 5000
+
+	// cancelCheckInterval is how many files are matched between checks for
+	// a cancelled (stale) query.
+	cancelCheckInterval = 1024
+)
+
+// filterResult is the outcome of one filter pass.
+type filterResult struct {
+	query   string
+	terms   []queryTerm
+	matches []matchedFile
+	// candidates are the files passing every term's prefilter. A later query
+	// that narrows this one only needs to look at these.
+	candidates []domain.TestFile
+	// candidateIndex holds each candidate's position in the unfiltered list.
+	candidateIndex []int
+}
+
+// filterResultMsg delivers an asynchronous filter pass back to the model.
+type filterResultMsg struct {
+	seq    uint64
+	result filterResult
+}
+
+// filterFuzzy filters files by the query language described in parseQuery
+// and sorts best matches first. frecency maps fileKey to a usage score that
+// boosts recently and frequently run files.
+// When query is empty, files with usage come first (highest frecency first),
+// followed by the rest in original order.
+func filterFuzzy(files []domain.TestFile, query string, frecency map[string]int) []matchedFile {
+	res, _ := runFilter(context.Background(), files, nil, query, frecency)
+	return res.matches
+}
+
+// runFilter evaluates query against files. When index is non-nil it gives
+// each file's position in the unfiltered list (files is then a candidate
+// subset from a previous pass). It returns false if ctx was cancelled.
+func runFilter(ctx context.Context, files []domain.TestFile, index []int, query string, frecency map[string]int) (filterResult, bool) {
+	res := filterResult{query: query, terms: parseQuery(query)}
+	pos := func(i int) int {
+		if index != nil {
+			return index[i]
+		}
+		return i
+	}
+
+	if len(res.terms) == 0 {
+		res.matches = make([]matchedFile, len(files))
+		for i, f := range files {
+			res.matches[i] = matchedFile{file: f, score: frecency[fileKey(f)], index: pos(i)}
+		}
+		res.candidates, res.candidateIndex = files, index
+		if len(frecency) > 0 {
+			rankTop(res.matches, rankLimit)
+		}
+		return res, true
+	}
+
+	for i, f := range files {
+		if i%cancelCheckInterval == 0 && ctx.Err() != nil {
+			return res, false
+		}
+
+		lowerPath := strings.ToLower(f.Path)
+		passes := true
+		for _, t := range res.terms {
+			if !t.prefilter(f, lowerPath) {
+				passes = false
+				break
+			}
+		}
+		if !passes {
+			continue
+		}
+		res.candidates = append(res.candidates, f)
+		res.candidateIndex = append(res.candidateIndex, pos(i))
+
+		ok, score, _ := matchTerms(res.terms, f, false)
+		if ok {
+			score += frecency[fileKey(f)] / frecencyDivisor
+			res.matches = append(res.matches, matchedFile{file: f, score: score, index: pos(i)})
+		}
+	}
+
+	rankTop(res.matches, rankLimit)
+	return res, true
+}
+
+// frecencyDivisor scales usage scores down so they break ties between
+// similar matches without overriding match quality.
+const frecencyDivisor = 10
+
+// rankTop moves the k best matches (highest score, then original position)
+// to the front in sorted order, leaving the rest in original order. This
+// avoids fully sorting very large result sets.
+func rankTop(matches []matchedFile, k int) {
+	if len(matches) <= k {
+		sort.SliceStable(matches, func(i, j int) bool {
+			return matches[i].score > matches[j].score
+		})
+		return
+	}
+
+	h := &matchHeap{}
+	for i := range matches {
+		if h.Len() < k {
+			heap.Push(h, matches[i])
+		} else if better(matches[i], (*h)[0]) {
+			(*h)[0] = matches[i]
+			heap.Fix(h, 0)
+		}
+	}
+
+	top := make([]matchedFile, h.Len())
+	inTop := make(map[int]bool, h.Len())
+	for i := len(top) - 1; i >= 0; i-- {
+		top[i] = heap.Pop(h).(matchedFile)
+		inTop[top[i].index] = true
+	}
+
+	rest := make([]matchedFile, 0, len(matches)-len(top))
+	for _, m := range matches {
+		if !inTop[m.index] {
+			rest = append(rest, m)
+		}
+	}
+	copy(matches, top)
+	copy(matches[len(top):], rest)
+}
+
+// better orders matches by score, breaking ties by original position.
+func better(a, b matchedFile) bool {
+	if a.score != b.score {
+		return a.score > b.score
+	}
+	return a.index < b.index
+}
+
+// matchHeap is a min-heap of matches (worst at the root) used by rankTop.
+type matchHeap []matchedFile
+
+func (h matchHeap) Len() int           { return len(h) }
+func (h matchHeap) Less(i, j int) bool { return better(h[j], h[i]) }
+func (h matchHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *matchHeap) Push(x any)        { *h = append(*h, x.(matchedFile)) }
+func (h *matchHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
+
+// filterCmd runs a filter pass off the UI goroutine.
+func filterCmd(ctx context.Context, seq uint64, files []domain.TestFile, index []int, query string, frecency map[string]int) tea.Cmd {
+	return func() tea.Msg {
+		res, ok := runFilter(ctx, files, index, query, frecency)
+		if !ok {
+			return nil
+		}
+		return filterResultMsg{seq: seq, result: res}
+	}
+}
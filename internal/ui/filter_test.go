@@ -0,0 +1,105 @@
+package ui
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/meijin/lazytest/internal/domain"
+)
+
+func TestNarrows(t *testing.T) {
+	tests := []struct {
+		prev, next string
+		want       bool
+	}{
+		{"us", "user", true},
+		{"usr", "usr test", true},
+		{"usr", "'usr", true},
+		{"'usr", "usr", false},
+		{"usr", "abc", false},
+		{"usr test", "usr", false},
+		{"@back", "@backend", true},
+		{"@back", "back", false},
+		{"is:failed", "is:passed", true},
+		{"!fixture", "user", true},
+		{"^app", "^app/models", true},
+	}
+	for _, tt := range tests {
+		if got := narrows(parseQuery(tt.prev), parseQuery(tt.next)); got != tt.want {
+			t.Errorf("narrows(%q, %q) = %v, want %v", tt.prev, tt.next, got, tt.want)
+		}
+	}
+}
+
+func TestRankTop(t *testing.T) {
+	scores := []int{3, 9, 1, 9, 5, 0, 7}
+	matches := func() []matchedFile {
+		m := make([]matchedFile, len(scores))
+		for i, s := range scores {
+			m[i] = matchedFile{score: s, index: i}
+		}
+		return m
+	}
+	indices := func(m []matchedFile) []int {
+		var out []int
+		for _, x := range m {
+			out = append(out, x.index)
+		}
+		return out
+	}
+
+	// Within the limit everything is sorted, ties keeping their order.
+	m := matches()
+	rankTop(m, len(m))
+	if got, want := indices(m), []int{1, 3, 6, 4, 0, 2, 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("full sort = %v, want %v", got, want)
+	}
+
+	// Beyond it only the best k are sorted; the rest keep their order.
+	m = matches()
+	rankTop(m, 3)
+	if got, want := indices(m), []int{1, 3, 6, 0, 2, 4, 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("top 3 = %v, want %v", got, want)
+	}
+}
+
+func TestRunFilterWithIndex(t *testing.T) {
+	files := []domain.TestFile{
+		{Path: "app/UserTest.php"},
+		{Path: "app/PostTest.php"},
+		{Path: "tests/UserServiceTest.php"},
+		{Path: "web/user.test.ts"},
+	}
+
+	first, ok := runFilter(context.Background(), files, nil, "user", nil)
+	if !ok {
+		t.Fatal("runFilter was cancelled")
+	}
+	if want := []int{0, 2, 3}; !reflect.DeepEqual(first.candidateIndex, want) {
+		t.Fatalf("candidateIndex = %v, want %v", first.candidateIndex, want)
+	}
+
+	// A narrower query only looks at the candidates but reports positions
+	// in the full list.
+	next, _ := runFilter(context.Background(), first.candidates, first.candidateIndex, "user php", nil)
+	var got []int
+	for _, m := range next.matches {
+		got = append(got, m.index)
+		if m.file != files[m.index] {
+			t.Errorf("match %d is %s, want %s", m.index, m.file.Path, files[m.index].Path)
+		}
+	}
+	if want := []int{0, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("match indices = %v, want %v", got, want)
+	}
+	if want := []int{0, 2}; !reflect.DeepEqual(next.candidateIndex, want) {
+		t.Errorf("candidateIndex = %v, want %v", next.candidateIndex, want)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, ok := runFilter(ctx, files, nil, "user", nil); ok {
+		t.Error("runFilter ignored a cancelled context")
+	}
+}
@@ -1,18 +1,18 @@
 package ui
 
 import (
-	"sort"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/meijin/lazytest/internal/domain"
 )
 
-// matchedFile holds a file along with its fuzzy match metadata.
+// matchedFile holds a file along with its match score. Highlight indices are
+// computed only for rows that are actually rendered.
 type matchedFile struct {
-	file    domain.TestFile
-	score   int
-	indices []int // byte indices in file.Path that matched the query
+	file  domain.TestFile
+	score int
+	index int // position in the unfiltered list, for stable ordering
 }
 
 // fuzzyScore returns whether query fuzzy-matches str, along with a score and
@@ -115,32 +115,6 @@ func boundaryBonus(str string, pos int) int {
 	return 0
 }
 
-// filterFuzzy filters files by fuzzy match and sorts best matches first.
-// When query is empty, all files are returned in original order.
-func filterFuzzy(files []domain.TestFile, query string) []matchedFile {
-	if query == "" {
-		result := make([]matchedFile, len(files))
-		for i, f := range files {
-			result[i] = matchedFile{file: f}
-		}
-		return result
-	}
-
-	var result []matchedFile
-	for _, f := range files {
-		ok, score, indices := fuzzyScore(query, f.Path)
-		if ok {
-			result = append(result, matchedFile{file: f, score: score, indices: indices})
-		}
-	}
-
-	sort.SliceStable(result, func(i, j int) bool {
-		return result[i].score > result[j].score
-	})
-
-	return result
-}
-
 // renderWithHighlight renders path with matched characters styled differently.
 func renderWithHighlight(path string, indices []int, base, highlight lipgloss.Style) string {
 	if len(indices) == 0 {
@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/meijin/lazytest/internal/config"
+	"github.com/meijin/lazytest/internal/domain"
+	"github.com/meijin/lazytest/internal/runner"
+)
+
+// RunHeadless runs files without the UI, for scripts and CI. Each target's
+// results are printed to w as plain text when the target finishes, then a
+// summary. It reports whether every target ran and no test failed.
+func RunHeadless(ctx context.Context, cfg config.Config, files []domain.TestFile, w io.Writer) bool {
+	applyIcons(cfg.Icons)
+
+	executor := runner.NewExecutor(cfg)
+	var m RunningModel
+	m.Reset(files, executor.RunNames)
+	ok := true
+	events, errs := executor.Run(ctx, files)
+	for ev := range events {
+		m.HandleEvent(ev)
+		if ev.Done {
+			if !printTargetResults(w, m.targetRuns[ev.TargetName], ev.TargetName) {
+				ok = false
+			}
+		}
+	}
+	if err := <-errs; err != nil {
+		fmt.Fprintf(w, "error: %v\n", err)
+		ok = false
+	}
+
+	run := m.BuildAggregatedRun(files)
+	fmt.Fprintf(w, "%s %d passed  %s %d failed  %s %d skipped  %s %s\n",
+		icons.Passed, run.Passed, icons.Failed, run.Failed, icons.Skipped, run.Skipped,
+		icons.Duration, run.Duration.Round(100*time.Millisecond))
+	return ok && run.Failed == 0
+}
+
+// printTargetResults prints the tests of one finished target, with the
+// messages of failed ones, and reports whether the target ran cleanly.
+func printTargetResults(w io.Writer, state *targetRunState, targetName string) bool {
+	fmt.Fprintln(w, targetName)
+	if state.errMsg != "" {
+		for _, line := range strings.Split(strings.TrimRight(state.errMsg, "\n"), "\n") {
+			fmt.Fprintf(w, "  error: %s\n", line)
+		}
+	}
+
+	for _, suite := range state.suites {
+		for _, tc := range suite.Tests {
+			icon := icons.Pending
+			switch tc.Status {
+			case domain.StatusPassed:
+				icon = icons.Passed
+			case domain.StatusFailed:
+				icon = icons.Failed
+			case domain.StatusSkipped:
+				icon = icons.Skipped
+			}
+			line := fmt.Sprintf("  %s %s › %s", icon, suite.Name, tc.Name)
+			if tc.Duration > 0 {
+				line += fmt.Sprintf(" (%dms)", tc.Duration.Milliseconds())
+			}
+			fmt.Fprintln(w, line)
+			if tc.Status == domain.StatusFailed && tc.Message != "" {
+				for _, msgLine := range strings.Split(strings.TrimRight(tc.Message, "\n"), "\n") {
+					fmt.Fprintf(w, "      %s\n", msgLine)
+				}
+			}
+		}
+	}
+	fmt.Fprintln(w)
+	return state.errMsg == ""
+}
@@ -1,6 +1,11 @@
 package ui
 
-import "github.com/charmbracelet/bubbles/key"
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/meijin/lazytest/internal/config"
+)
 
 // SearchKeyMap defines key bindings for the search mode.
 type SearchKeyMap struct {
@@ -9,6 +14,9 @@ type SearchKeyMap struct {
 	SelectAll key.Binding
 	Up        key.Binding
 	Down      key.Binding
+	Rescan    key.Binding
+	SaveSet   key.Binding
+	Sets      key.Binding
 	Quit      key.Binding
 }
 
@@ -33,6 +41,60 @@ var searchKeys = SearchKeyMap{
 		key.WithKeys("down", "ctrl+n", "ctrl+j"),
 		key.WithHelp("↓", "down"),
 	),
+	Rescan: key.NewBinding(
+		key.WithKeys("ctrl+r"),
+		key.WithHelp("Ctrl+R", "rescan"),
+	),
+	SaveSet: key.NewBinding(
+		key.WithKeys("ctrl+s"),
+		key.WithHelp("Ctrl+S", "save set"),
+	),
+	Sets: key.NewBinding(
+		key.WithKeys("ctrl+o"),
+		key.WithHelp("Ctrl+O", "sets"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c"),
+		key.WithHelp("Ctrl+C", "quit"),
+	),
+}
+
+// SetsKeyMap defines key bindings for the test set picker.
+type SetsKeyMap struct {
+	Up     key.Binding
+	Down   key.Binding
+	Load   key.Binding
+	Save   key.Binding
+	Delete key.Binding
+	Close  key.Binding
+	Quit   key.Binding
+}
+
+var setsKeys = SetsKeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "ctrl+p", "ctrl+k"),
+		key.WithHelp("↑", "up"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "ctrl+n", "ctrl+j"),
+		key.WithHelp("↓", "down"),
+	),
+	Load: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("Enter", "load"),
+	),
+	Save: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("Enter", "save"),
+	),
+	Delete: key.NewBinding(
+		key.WithKeys("ctrl+d"),
+		key.WithHelp("Ctrl+D", "delete"),
+	),
+	Close: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("Esc", "back"),
+	),
 	Quit: key.NewBinding(
 		key.WithKeys("ctrl+c"),
 		key.WithHelp("Ctrl+C", "quit"),
@@ -67,6 +129,7 @@ type ResultsKeyMap struct {
 	Rerun    key.Binding
 	RerunAll key.Binding
 	Filter   key.Binding
+	Matrix   key.Binding
 	Open     key.Binding
 	Quit     key.Binding
 }
@@ -90,7 +153,7 @@ var resultsKeys = ResultsKeyMap{
 	),
 	Enter: key.NewBinding(
 		key.WithKeys("enter"),
-		key.WithHelp("Enter", "back to search"),
+		key.WithHelp("Enter", "search"),
 	),
 	Back: key.NewBinding(
 		key.WithKeys("esc"),
@@ -106,14 +169,137 @@ var resultsKeys = ResultsKeyMap{
 	),
 	Filter: key.NewBinding(
 		key.WithKeys("f"),
-		key.WithHelp("f", "fails only"),
+		key.WithHelp("f", "fails"),
+	),
+	Matrix: key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "matrix"),
 	),
 	Open: key.NewBinding(
 		key.WithKeys("o"),
-		key.WithHelp("o", "open in editor"),
+		key.WithHelp("o", "open"),
 	),
 	Quit: key.NewBinding(
 		key.WithKeys("q", "ctrl+c"),
 		key.WithHelp("q", "quit"),
 	),
 }
+
+// applyKeys replaces the keys of the bindings k remaps. The help bar shows
+// the first key of each.
+func applyKeys(k config.Keys) {
+	rebind(&searchKeys.Run, k.Search.Run)
+	rebind(&searchKeys.Toggle, k.Search.Toggle)
+	rebind(&searchKeys.SelectAll, k.Search.SelectAll)
+	rebind(&searchKeys.Up, k.Search.Up)
+	rebind(&searchKeys.Down, k.Search.Down)
+	rebind(&searchKeys.Rescan, k.Search.Rescan)
+	rebind(&searchKeys.SaveSet, k.Search.SaveSet)
+	rebind(&searchKeys.Sets, k.Search.Sets)
+	rebind(&searchKeys.Quit, k.Search.Quit)
+
+	rebind(&setsKeys.Up, k.Sets.Up)
+	rebind(&setsKeys.Down, k.Sets.Down)
+	rebind(&setsKeys.Load, k.Sets.Load)
+	rebind(&setsKeys.Save, k.Sets.Save)
+	rebind(&setsKeys.Delete, k.Sets.Delete)
+	rebind(&setsKeys.Close, k.Sets.Close)
+	rebind(&setsKeys.Quit, k.Sets.Quit)
+
+	rebind(&runningKeys.Cancel, k.Running.Cancel)
+	rebind(&runningKeys.Quit, k.Running.Quit)
+
+	resultsKeys.apply(k.Results)
+}
+
+// apply replaces the keys of the bindings k remaps.
+func (km *ResultsKeyMap) apply(k config.ResultsKeys) {
+	rebind(&km.Up, k.Up)
+	rebind(&km.Down, k.Down)
+	rebind(&km.Left, k.Left)
+	rebind(&km.Right, k.Right)
+	rebind(&km.Enter, k.Enter)
+	rebind(&km.Back, k.Back)
+	rebind(&km.Rerun, k.Rerun)
+	rebind(&km.RerunAll, k.RerunAll)
+	rebind(&km.Filter, k.Filter)
+	rebind(&km.Matrix, k.Matrix)
+	rebind(&km.Open, k.Open)
+	rebind(&km.Quit, k.Quit)
+}
+
+// namedBinding is a binding with its name in the user config.
+type namedBinding struct {
+	name    string
+	binding key.Binding
+}
+
+// named returns the bindings with their names in the user config's
+// keys.results.
+func (km ResultsKeyMap) named() []namedBinding {
+	return []namedBinding{
+		{"up", km.Up}, {"down", km.Down},
+		{"left", km.Left}, {"right", km.Right},
+		{"enter", km.Enter}, {"back", km.Back},
+		{"rerun", km.Rerun}, {"rerun_all", km.RerunAll},
+		{"filter", km.Filter}, {"matrix", km.Matrix},
+		{"open", km.Open}, {"quit", km.Quit},
+	}
+}
+
+// ResultsBindings returns the name of the built-in results binding of each
+// key, as in the user config's keys.results, with the remaps in k applied.
+// Configured actions can't use these keys.
+func ResultsBindings(k config.Keys) map[string]string {
+	km := resultsKeys
+	km.apply(k.Results)
+	bindings := make(map[string]string)
+	for _, b := range km.named() {
+		for _, key := range b.binding.Keys() {
+			if key == " " {
+				key = "space"
+			}
+			bindings[key] = b.name
+		}
+	}
+	return bindings
+}
+
+func rebind(b *key.Binding, keys config.KeyList) {
+	if len(keys) == 0 {
+		return
+	}
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		// Bubble Tea reports the space bar as " ".
+		if k == "space" {
+			k = " "
+		}
+		names[i] = k
+	}
+	b.SetKeys(names...)
+	b.SetHelp(keyLabel(names[0]), b.Help().Desc)
+}
+
+// keyLabels spells out keys whose Bubble Tea names read poorly in help.
+var keyLabels = map[string]string{
+	"up": "↑", "down": "↓", "left": "←", "right": "→", " ": "Space",
+}
+
+// keyLabel returns how key is shown in the help bar: "ctrl+a" as "Ctrl+A",
+// "enter" as "Enter", single characters as they are.
+func keyLabel(k string) string {
+	if label, ok := keyLabels[k]; ok {
+		return label
+	}
+	if len(k) == 1 {
+		return k
+	}
+	parts := strings.Split(k, "+")
+	for i, p := range parts {
+		if p != "" {
+			parts[i] = strings.ToUpper(p[:1]) + p[1:]
+		}
+	}
+	return strings.Join(parts, "+")
+}
@@ -0,0 +1,170 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/meijin/lazytest/internal/domain"
+)
+
+// matrixGroup is a target run in several matrix variants, with its tests
+// side by side.
+type matrixGroup struct {
+	target     string
+	targetType string
+	variants   []string
+	rows       []matrixRow
+}
+
+// matrixRow is one test of a matrix target with its status in each
+// variant; StatusPending where it didn't run.
+type matrixRow struct {
+	suite    string
+	test     string
+	statuses []domain.TestStatus
+}
+
+// divergent reports whether the test failed in some variants but not in
+// all of them. Variants that didn't report the test don't count.
+func (r matrixRow) divergent() bool {
+	ran, failed := 0, 0
+	for _, s := range r.statuses {
+		if s == domain.StatusPending {
+			continue
+		}
+		ran++
+		if s == domain.StatusFailed {
+			failed++
+		}
+	}
+	return failed > 0 && failed < ran
+}
+
+func (r matrixRow) failed() bool {
+	for _, s := range r.statuses {
+		if s == domain.StatusFailed {
+			return true
+		}
+	}
+	return false
+}
+
+// buildMatrixGroups collects the runs of matrix variants in run by target.
+// Within a group, tests failing in only some variants come first.
+func buildMatrixGroups(run *domain.AggregatedRun) []matrixGroup {
+	var groups []matrixGroup
+	index := make(map[string]int)
+	for _, r := range run.Runs {
+		target, variant := domain.SplitRunName(r.TargetName)
+		if variant == "" {
+			continue
+		}
+		i, ok := index[target]
+		if !ok {
+			i = len(groups)
+			index[target] = i
+			groups = append(groups, matrixGroup{target: target, targetType: r.TargetType})
+		}
+		groups[i].variants = append(groups[i].variants, variant)
+	}
+
+	for gi := range groups {
+		g := &groups[gi]
+		rowIndex := make(map[string]int)
+		for vi, variant := range g.variants {
+			for _, r := range run.Runs {
+				if r.TargetName != domain.RunName(g.target, variant) {
+					continue
+				}
+				for _, suite := range r.Suites {
+					for _, tc := range suite.Tests {
+						id := suite.Name + "\x00" + tc.Name
+						ri, ok := rowIndex[id]
+						if !ok {
+							ri = len(g.rows)
+							rowIndex[id] = ri
+							g.rows = append(g.rows, matrixRow{
+								suite:    suite.Name,
+								test:     tc.Name,
+								statuses: make([]domain.TestStatus, len(g.variants)),
+							})
+						}
+						g.rows[ri].statuses[vi] = tc.Status
+					}
+				}
+			}
+		}
+		sort.SliceStable(g.rows, func(i, j int) bool {
+			return g.rows[i].divergent() && !g.rows[j].divergent()
+		})
+	}
+	return groups
+}
+
+// renderMatrixView shows each matrix target's tests with a status column
+// per variant, highlighting the tests that fail in only some variants.
+func (m ResultsModel) renderMatrixView(width, height int) string {
+	lines := m.matrixLines()
+	for i := range lines {
+		lines[i] = ansi.Truncate(lines[i], width, "")
+	}
+	if m.scrollY > 0 && m.scrollY < len(lines) {
+		lines = lines[m.scrollY:]
+	}
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// matrixLines returns the lines of the matrix view, before scrolling.
+func (m ResultsModel) matrixLines() []string {
+	var lines []string
+	for _, g := range m.matrix {
+		divergent := 0
+		for _, r := range g.rows {
+			if r.divergent() {
+				divergent++
+			}
+		}
+		summary := passedStyle.Render("all variants agree")
+		if divergent > 0 {
+			summary = failedStyle.Render(fmt.Sprintf("%d test(s) fail in only some variants", divergent))
+		}
+		lines = append(lines, targetBadge(g.target, g.targetType)+" "+suiteNameStyle.Render(g.target)+"  "+summary)
+
+		widths := make([]int, len(g.variants))
+		var header []string
+		for i, v := range g.variants {
+			widths[i] = max(lipgloss.Width(v), lipgloss.Width(icons.Passed))
+			header = append(header, durationStyle.Render(fmt.Sprintf("%-*s", widths[i], v)))
+		}
+		lines = append(lines, "  "+strings.Join(header, " "))
+
+		for _, r := range g.rows {
+			if m.filterFails && !r.failed() {
+				continue
+			}
+			var cells []string
+			for i, s := range r.statuses {
+				icon := statusIcon(s)
+				cells = append(cells, icon+strings.Repeat(" ", widths[i]-lipgloss.Width(icon)))
+			}
+			name := shortSuiteName(r.suite) + " › " + r.test
+			if r.divergent() {
+				name = failedStyle.Render(name)
+			} else {
+				name = testNameStyle.Render(name)
+			}
+			lines = append(lines, "  "+strings.Join(cells, " ")+"  "+name)
+		}
+		lines = append(lines, "")
+	}
+	return lines
+}
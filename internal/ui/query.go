@@ -0,0 +1,253 @@
+package ui
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/meijin/lazytest/internal/domain"
+)
+
+// termKind identifies how a single query term is matched.
+type termKind int
+
+const (
+	termFuzzy  termKind = iota // plain text: fuzzy subsequence on the path
+	termExact                  // 'text: case-insensitive substring
+	termPrefix                 // ^text: path starts with text
+	termSuffix                 // text$: path ends with text
+	termTarget                 // @name: target name
+	termType                   // type:vitest: target type
+	termStatus                 // is:failed / is:passed / is:new
+	termDir                    // dir:path: file lives under the directory, from the root
+)
+
+// queryTerm is one space-separated token of a search query.
+type queryTerm struct {
+	kind   termKind
+	text   string // lowercased operand
+	negate bool
+}
+
+// parseQuery splits a query into AND-ed terms using fzf-style operators:
+//
+//	foo       fuzzy match
+//	'foo      exact substring
+//	^foo      path prefix
+//	foo$      path suffix
+//	!foo      negation (exact substring unless combined with ^ or $)
+//	@vitest   target name prefix
+//	type:jest target type (vitest, jest, phpunit, ...)
+//	is:failed previous status (failed, passed, new)
+//	dir:app/Models  files under the directory
+func parseQuery(query string) []queryTerm {
+	var terms []queryTerm
+	for _, tok := range strings.Fields(query) {
+		t := queryTerm{kind: termFuzzy}
+		if strings.HasPrefix(tok, "!") && len(tok) > 1 {
+			t.negate = true
+			t.kind = termExact
+			tok = tok[1:]
+		}
+
+		lower := strings.ToLower(tok)
+		switch {
+		case strings.HasPrefix(lower, "@") && len(lower) > 1:
+			t.kind = termTarget
+			lower = lower[1:]
+		case strings.HasPrefix(lower, "type:") && len(lower) > 5:
+			t.kind = termType
+			lower = lower[5:]
+		case strings.HasPrefix(lower, "is:") && len(lower) > 3:
+			t.kind = termStatus
+			lower = lower[3:]
+		case strings.HasPrefix(lower, "dir:") && len(lower) > 4:
+			t.kind = termDir
+			lower = strings.Trim(lower[4:], "/")
+		case strings.HasPrefix(lower, "'") && len(lower) > 1:
+			t.kind = termExact
+			lower = lower[1:]
+		case strings.HasPrefix(lower, "^") && len(lower) > 1:
+			t.kind = termPrefix
+			lower = lower[1:]
+		case strings.HasSuffix(lower, "$") && len(lower) > 1:
+			t.kind = termSuffix
+			lower = lower[:len(lower)-1]
+		}
+		t.text = lower
+		terms = append(terms, t)
+	}
+	return terms
+}
+
+// matchTerms reports whether f satisfies every term, returning the combined
+// score and, when wantIndices is set, the highlighted byte indices in f.Path.
+func matchTerms(terms []queryTerm, f domain.TestFile, wantIndices bool) (bool, int, []int) {
+	lowerPath := strings.ToLower(f.Path)
+	score := 0
+	var indices []int
+
+	for _, t := range terms {
+		ok, s, idx := t.match(f, lowerPath)
+		if t.negate {
+			if ok {
+				return false, 0, nil
+			}
+			continue
+		}
+		if !ok {
+			return false, 0, nil
+		}
+		score += s
+		if wantIndices {
+			indices = append(indices, idx...)
+		}
+	}
+
+	if len(indices) > 1 {
+		sort.Ints(indices)
+		indices = dedupInts(indices)
+	}
+	return true, score, indices
+}
+
+// match evaluates a single term (ignoring negation) against f.
+func (t queryTerm) match(f domain.TestFile, lowerPath string) (bool, int, []int) {
+	switch t.kind {
+	case termFuzzy:
+		return fuzzyScore(t.text, f.Path)
+	case termExact:
+		idx := strings.Index(lowerPath, t.text)
+		if idx == -1 {
+			return false, 0, nil
+		}
+		return true, 100 + boundaryBonus(lowerPath, idx), spanIndices(idx, len(t.text))
+	case termPrefix:
+		if !strings.HasPrefix(lowerPath, t.text) {
+			return false, 0, nil
+		}
+		return true, 100, spanIndices(0, len(t.text))
+	case termSuffix:
+		if !strings.HasSuffix(lowerPath, t.text) {
+			return false, 0, nil
+		}
+		return true, 100, spanIndices(len(lowerPath)-len(t.text), len(t.text))
+	case termTarget:
+		return strings.HasPrefix(strings.ToLower(f.TargetName), t.text), 0, nil
+	case termType:
+		return strings.HasPrefix(f.TargetType, t.text), 0, nil
+	case termStatus:
+		return statusMatches(t.text, f.PrevStatus), 0, nil
+	case termDir:
+		return strings.HasPrefix(lowerPath, t.text+"/"), 0, nil
+	}
+	return false, 0, nil
+}
+
+// prefilterClass groups term kinds by the cheap necessary condition that
+// prefilter checks for them.
+type prefilterClass int
+
+const (
+	prefilterNone        prefilterClass = iota // always passes
+	prefilterSubsequence                       // text is a subsequence of the path
+	prefilterSubstring                         // text is a substring of the path
+	prefilterTarget                            // target name starts with text
+	prefilterType                              // target type starts with text
+)
+
+func (t queryTerm) prefilterClass() prefilterClass {
+	if t.negate {
+		return prefilterNone
+	}
+	switch t.kind {
+	case termFuzzy:
+		return prefilterSubsequence
+	case termExact, termPrefix, termSuffix, termDir:
+		return prefilterSubstring
+	case termTarget:
+		return prefilterTarget
+	case termType:
+		return prefilterType
+	}
+	return prefilterNone
+}
+
+// prefilter is a cheap necessary condition for t to match. Unlike match, it
+// only ever gets stricter as the term's text is extended, so the files that
+// pass it for a query are a valid starting set for any narrower query.
+func (t queryTerm) prefilter(f domain.TestFile, lowerPath string) bool {
+	switch t.prefilterClass() {
+	case prefilterSubsequence:
+		return isSubsequence(t.text, lowerPath)
+	case prefilterSubstring:
+		return strings.Contains(lowerPath, t.text)
+	case prefilterTarget:
+		return strings.HasPrefix(strings.ToLower(f.TargetName), t.text)
+	case prefilterType:
+		return strings.HasPrefix(f.TargetType, t.text)
+	}
+	return true
+}
+
+// narrows reports whether every file matching next also passes the
+// prefilters of prev, i.e. whether next can be evaluated against the
+// candidates collected for prev instead of the full file list.
+func narrows(prev, next []queryTerm) bool {
+	if len(next) < len(prev) {
+		return false
+	}
+	for i, p := range prev {
+		n := next[i]
+		pc, nc := p.prefilterClass(), n.prefilterClass()
+		if pc == prefilterNone {
+			continue
+		}
+		compatible := pc == nc || (pc == prefilterSubsequence && nc == prefilterSubstring)
+		if !compatible || !strings.HasPrefix(n.text, p.text) {
+			return false
+		}
+	}
+	return true
+}
+
+func isSubsequence(query, s string) bool {
+	qi := 0
+	for si := 0; si < len(s) && qi < len(query); si++ {
+		if s[si] == query[qi] {
+			qi++
+		}
+	}
+	return qi == len(query)
+}
+
+// statusMatches maps an is: qualifier onto a previous run status.
+func statusMatches(name string, status domain.TestStatus) bool {
+	switch name {
+	case "failed", "fail":
+		return status == domain.StatusFailed
+	case "passed", "pass":
+		return status == domain.StatusPassed
+	case "new":
+		return status == domain.StatusPending
+	}
+	return false
+}
+
+func spanIndices(start, n int) []int {
+	indices := make([]int, n)
+	for i := range indices {
+		indices[i] = start + i
+	}
+	return indices
+}
+
+// dedupInts removes adjacent duplicates from a sorted slice.
+func dedupInts(s []int) []int {
+	out := s[:1]
+	for _, v := range s[1:] {
+		if v != out[len(out)-1] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
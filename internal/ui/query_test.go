@@ -0,0 +1,97 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/meijin/lazytest/internal/domain"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []queryTerm
+	}{
+		{"", nil},
+		{"User", []queryTerm{{kind: termFuzzy, text: "user"}}},
+		{"'Repo ^tests/ .php$", []queryTerm{
+			{kind: termExact, text: "repo"},
+			{kind: termPrefix, text: "tests/"},
+			{kind: termSuffix, text: ".php"},
+		}},
+		{"!fixture !^vendor", []queryTerm{
+			{kind: termExact, text: "fixture", negate: true},
+			{kind: termPrefix, text: "vendor", negate: true},
+		}},
+		{"@Backend is:failed dir:/app/Models/", []queryTerm{
+			{kind: termTarget, text: "backend"},
+			{kind: termStatus, text: "failed"},
+			{kind: termDir, text: "app/models"},
+		}},
+		// Operators without an operand are plain text.
+		{"! ' ^ $ @ is: dir:", []queryTerm{
+			{kind: termFuzzy, text: "!"},
+			{kind: termFuzzy, text: "'"},
+			{kind: termFuzzy, text: "^"},
+			{kind: termFuzzy, text: "$"},
+			{kind: termFuzzy, text: "@"},
+			{kind: termFuzzy, text: "is:"},
+			{kind: termFuzzy, text: "dir:"},
+		}},
+	}
+	for _, tt := range tests {
+		if got := parseQuery(tt.query); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseQuery(%q) = %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestMatchTerms(t *testing.T) {
+	model := domain.TestFile{Path: "app/Models/UserTest.php", TargetName: "backend", PrevStatus: domain.StatusFailed}
+	nested := domain.TestFile{Path: "src/app/Models/UserTest.php", TargetName: "backend", PrevStatus: domain.StatusPassed}
+	web := domain.TestFile{Path: "web/src/user.test.ts", TargetName: "frontend"}
+
+	tests := []struct {
+		query string
+		file  domain.TestFile
+		want  bool
+	}{
+		{"usrtst", model, true},
+		{"tsu", model, false},
+		{"'models/user", model, true},
+		{"'modles", model, false},
+		{"^app/", model, true},
+		{"^app/", nested, false},
+		{"test.php$", model, true},
+		{"test.php$", web, false},
+		{"!fixture", model, true},
+		{"!user", model, false},
+		{"!^src/", nested, false},
+		{"@back", model, true},
+		{"@back", web, false},
+		{"is:failed", model, true},
+		{"is:passed", model, false},
+		{"is:new", web, true},
+		{"dir:app/Models", model, true},
+		{"dir:app/models/", model, true},
+		{"dir:app/Models", nested, false},
+		{"dir:app/Mod", model, false},
+		{"!dir:src", nested, false},
+		{"user @backend !is:passed dir:app", model, true},
+		{"user @backend !is:passed dir:app", nested, false},
+	}
+	for _, tt := range tests {
+		if got, _, _ := matchTerms(parseQuery(tt.query), tt.file, false); got != tt.want {
+			t.Errorf("matchTerms(%q, %s) = %v, want %v", tt.query, tt.file.Path, got, tt.want)
+		}
+	}
+}
+
+func TestMatchTermsIndices(t *testing.T) {
+	f := domain.TestFile{Path: "tests/UserTest.php"}
+	_, _, got := matchTerms(parseQuery("^tests 'user !fixture"), f, true)
+	want := []int{0, 1, 2, 3, 4, 6, 7, 8, 9}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("indices = %v, want %v", got, want)
+	}
+}
@@ -18,7 +18,9 @@ type ResultsModel struct {
 	cursor      int
 	focusDetail bool
 	filterFails bool
-	scrollY     int // detail scroll offset
+	matrix      []matrixGroup // targets run in several matrix variants
+	combined    bool          // showing the matrix view
+	scrollY     int           // detail scroll offset
 	width       int
 	height      int
 }
@@ -27,6 +29,7 @@ type resultItem struct {
 	suite      *domain.TestSuite
 	test       *domain.TestCase
 	targetName string
+	targetType string
 	depth      int // 0 = target header, 1 = suite, 2 = test
 }
 
@@ -39,10 +42,17 @@ func (m *ResultsModel) SetRun(run *domain.AggregatedRun) {
 	m.cursor = 0
 	m.focusDetail = false
 	m.filterFails = false
+	m.combined = false
 	m.scrollY = 0
+	m.matrix = buildMatrixGroups(run)
 	m.buildFlatList()
 }
 
+// HasMatrix reports whether the run has matrix variants to compare.
+func (m *ResultsModel) HasMatrix() bool {
+	return len(m.matrix) > 0
+}
+
 func (m *ResultsModel) buildFlatList() {
 	m.flatList = nil
 	if m.run == nil {
@@ -50,7 +60,7 @@ func (m *ResultsModel) buildFlatList() {
 	}
 	for _, run := range m.run.Runs {
 		// Add target header
-		m.flatList = append(m.flatList, &resultItem{targetName: run.TargetName, depth: 0})
+		m.flatList = append(m.flatList, &resultItem{targetName: run.TargetName, targetType: run.TargetType, depth: 0})
 
 		for _, suite := range run.Suites {
 			if m.filterFails && suite.ComputeStatus() != domain.StatusFailed {
@@ -99,7 +109,15 @@ func (m *ResultsModel) SelectedSuite() *domain.TestSuite {
 func (m ResultsModel) Update(msg tea.Msg) (ResultsModel, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.combined {
+			return m.updateMatrixView(msg), nil
+		}
 		switch {
+		case key.Matches(msg, resultsKeys.Matrix):
+			if m.HasMatrix() {
+				m.combined = true
+				m.scrollY = 0
+			}
 		case key.Matches(msg, resultsKeys.Up):
 			if m.focusDetail {
 				if m.scrollY > 0 {
@@ -134,11 +152,38 @@ func (m ResultsModel) Update(msg tea.Msg) (ResultsModel, tea.Cmd) {
 	return m, nil
 }
 
+// updateMatrixView handles keys in the matrix view: scrolling, the
+// failures filter and going back to the tree.
+func (m ResultsModel) updateMatrixView(msg tea.KeyMsg) ResultsModel {
+	switch {
+	case key.Matches(msg, resultsKeys.Matrix):
+		m.combined = false
+		m.scrollY = 0
+	case key.Matches(msg, resultsKeys.Up):
+		if m.scrollY > 0 {
+			m.scrollY--
+		}
+	case key.Matches(msg, resultsKeys.Down):
+		if m.scrollY < len(m.matrixLines())-1 {
+			m.scrollY++
+		}
+	case key.Matches(msg, resultsKeys.Filter):
+		m.filterFails = !m.filterFails
+		m.cursor = 0
+		m.scrollY = 0
+		m.buildFlatList()
+	}
+	return m
+}
+
 func (m ResultsModel) View(width, height int) string {
 	if m.run == nil {
 		return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center,
 			pendingStyle.Render("No results to display"))
 	}
+	if m.combined {
+		return activeBoxStyle.Width(width - 2).Height(height).Render(m.renderMatrixView(width-2, height))
+	}
 
 	// Split: left 45%, right 55%
 	leftWidth := width*45/100 - 2
@@ -189,7 +234,7 @@ func (m ResultsModel) renderTreeView(width, height int) string {
 		switch {
 		case item.depth == 0 && item.suite == nil && item.test == nil:
 			// Target header
-			badge := targetBadge(item.targetName)
+			badge := targetBadge(item.targetName, item.targetType)
 			if selected {
 				line = fmt.Sprintf("%s %s", badge, selectedItemStyle.Render(item.targetName))
 			} else {
@@ -197,7 +242,7 @@ func (m ResultsModel) renderTreeView(width, height int) string {
 			}
 
 		case item.suite != nil:
-			icon := statusStyle(item.suite.ComputeStatus().Icon()).Render(item.suite.ComputeStatus().Icon())
+			icon := statusIcon(item.suite.ComputeStatus())
 			name := shortSuiteName(item.suite.Name)
 			if lipgloss.Width(name) > width-6 {
 				name = "..." + name[len(name)-width+7:]
@@ -209,7 +254,7 @@ func (m ResultsModel) renderTreeView(width, height int) string {
 			}
 
 		case item.test != nil:
-			icon := statusStyle(item.test.Status.Icon()).Render(item.test.Status.Icon())
+			icon := statusIcon(item.test.Status)
 			dur := ""
 			if item.test.Duration > 0 {
 				dur = durationStyle.Render(fmt.Sprintf(" %dms", item.test.Duration.Milliseconds()))
@@ -262,7 +307,7 @@ func (m ResultsModel) renderDetailView(width, height int) string {
 	switch {
 	case item.depth == 0 && item.suite == nil && item.test == nil:
 		// Target header - show target summary
-		badge := targetBadge(item.targetName)
+		badge := targetBadge(item.targetName, item.targetType)
 		lines = append(lines, badge+" "+titleStyle.Render(item.targetName))
 		lines = append(lines, "")
 
@@ -271,12 +316,12 @@ func (m ResultsModel) renderDetailView(width, height int) string {
 			if run.TargetName == item.targetName {
 				total := run.Passed + run.Failed + run.Skipped
 				lines = append(lines, normalItemStyle.Render(fmt.Sprintf("  Tests: %d", total)))
-				lines = append(lines, fmt.Sprintf("  %s %d passed", passedStyle.Render("✓"), run.Passed))
+				lines = append(lines, fmt.Sprintf("  %s %d passed", passedStyle.Render(icons.Passed), run.Passed))
 				if run.Failed > 0 {
-					lines = append(lines, fmt.Sprintf("  %s %d failed", failedStyle.Render("✗"), run.Failed))
+					lines = append(lines, fmt.Sprintf("  %s %d failed", failedStyle.Render(icons.Failed), run.Failed))
 				}
 				if run.Skipped > 0 {
-					lines = append(lines, fmt.Sprintf("  %s %d skipped", skippedStyle.Render("⊘"), run.Skipped))
+					lines = append(lines, fmt.Sprintf("  %s %d skipped", skippedStyle.Render(icons.Skipped), run.Skipped))
 				}
 				lines = append(lines, "")
 				lines = append(lines, durationStyle.Render(fmt.Sprintf("  Duration: %dms", run.Duration.Milliseconds())))
@@ -301,12 +346,12 @@ func (m ResultsModel) renderDetailView(width, height int) string {
 		}
 		total := passed + failed + skipped
 		lines = append(lines, normalItemStyle.Render(fmt.Sprintf("  Tests: %d", total)))
-		lines = append(lines, fmt.Sprintf("  %s %d passed", passedStyle.Render("✓"), passed))
+		lines = append(lines, fmt.Sprintf("  %s %d passed", passedStyle.Render(icons.Passed), passed))
 		if failed > 0 {
-			lines = append(lines, fmt.Sprintf("  %s %d failed", failedStyle.Render("✗"), failed))
+			lines = append(lines, fmt.Sprintf("  %s %d failed", failedStyle.Render(icons.Failed), failed))
 		}
 		if skipped > 0 {
-			lines = append(lines, fmt.Sprintf("  %s %d skipped", skippedStyle.Render("⊘"), skipped))
+			lines = append(lines, fmt.Sprintf("  %s %d skipped", skippedStyle.Render(icons.Skipped), skipped))
 		}
 		if item.suite.Duration > 0 {
 			lines = append(lines, "")
@@ -323,7 +368,7 @@ func (m ResultsModel) renderDetailView(width, height int) string {
 			lines = append(lines, "")
 			lines = append(lines, failedStyle.Render("  Failed:"))
 			for _, tc := range failedTests {
-				lines = append(lines, failedStyle.Render("    ✗ ")+testNameStyle.Render(tc.Name))
+				lines = append(lines, failedStyle.Render("    "+icons.Failed+" ")+testNameStyle.Render(tc.Name))
 				if tc.Message != "" {
 					lines = append(lines, detailBodyStyle.Render("      "+tc.Message))
 				}
@@ -334,21 +379,25 @@ func (m ResultsModel) renderDetailView(width, height int) string {
 		tc := item.test
 
 		var headerStyle lipgloss.Style
+		icon := icons.Pending
 		switch tc.Status {
 		case domain.StatusPassed:
-			headerStyle = passedStyle
+			headerStyle, icon = passedStyle, icons.Passed
 		case domain.StatusFailed:
-			headerStyle = failedStyle
+			headerStyle, icon = failedStyle, icons.Failed
 		case domain.StatusSkipped:
-			headerStyle = skippedStyle
+			headerStyle, icon = skippedStyle, icons.Skipped
 		default:
 			headerStyle = normalItemStyle
 		}
-		lines = append(lines, headerStyle.Render(tc.Status.Icon()+" "+tc.Name))
+		lines = append(lines, headerStyle.Render(icon+" "+tc.Name))
 
 		if tc.Duration > 0 {
 			lines = append(lines, durationStyle.Render(fmt.Sprintf("  %dms", tc.Duration.Milliseconds())))
 		}
+		if tc.Location != "" {
+			lines = append(lines, durationStyle.Render("  "+strings.TrimPrefix(tc.Location, "file://")))
+		}
 		lines = append(lines, "")
 
 		switch tc.Status {
@@ -374,6 +423,14 @@ func (m ResultsModel) renderDetailView(width, height int) string {
 				lines = append(lines, detailBodyStyle.Render("  "+tc.Message))
 			}
 		}
+
+		if out := strings.TrimRight(tc.Output, "\n"); out != "" {
+			lines = append(lines, "")
+			lines = append(lines, normalItemStyle.Render("  Output:"))
+			for _, ol := range strings.Split(out, "\n") {
+				lines = append(lines, detailBodyStyle.Render("  "+ol))
+			}
+		}
 	}
 
 	// Truncate lines to fit within width to prevent lipgloss word-wrapping
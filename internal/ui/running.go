@@ -20,7 +20,8 @@ type targetRunState struct {
 // RunningModel displays real-time test execution progress.
 type RunningModel struct {
 	targetRuns  map[string]*targetRunState
-	targetOrder []string // preserve insertion order
+	targetOrder []string          // preserve insertion order
+	targetTypes map[string]string // target name -> type, for badges
 	width       int
 	height      int
 }
@@ -30,17 +31,20 @@ func NewRunningModel() RunningModel {
 }
 
 // Reset clears state and pre-registers expected targets so AllDone()
-// won't return true before slower targets produce any output.
-func (m *RunningModel) Reset(files []domain.TestFile) {
+// won't return true before slower targets produce any output. runNames
+// returns the runs a target's files are run as, one per matrix variant.
+func (m *RunningModel) Reset(files []domain.TestFile, runNames func(target string) []string) {
 	m.targetRuns = make(map[string]*targetRunState)
 	m.targetOrder = nil
+	m.targetTypes = make(map[string]string)
 
-	seen := make(map[string]bool)
 	for _, f := range files {
-		if !seen[f.TargetName] {
-			seen[f.TargetName] = true
-			m.targetRuns[f.TargetName] = &targetRunState{}
-			m.targetOrder = append(m.targetOrder, f.TargetName)
+		for _, name := range runNames(f.TargetName) {
+			if _, seen := m.targetRuns[name]; !seen {
+				m.targetRuns[name] = &targetRunState{}
+				m.targetOrder = append(m.targetOrder, name)
+				m.targetTypes[name] = f.TargetType
+			}
 		}
 	}
 }
@@ -73,9 +77,17 @@ func (m *RunningModel) HandleEvent(te *runner.TargetEvent) {
 
 	switch ev.Type {
 	case parser.EventSuiteStarted:
+		// Reporters of parallel runners re-announce a suite to switch back to it.
+		for _, s := range state.suites {
+			if s.Name == ev.Name && s.Status == domain.StatusRunning {
+				state.current = s
+				return
+			}
+		}
 		suite := &domain.TestSuite{
-			Name:   ev.Name,
-			Status: domain.StatusRunning,
+			Name:     ev.Name,
+			Status:   domain.StatusRunning,
+			Location: ev.Location,
 		}
 		state.suites = append(state.suites, suite)
 		state.current = suite
@@ -89,9 +101,10 @@ func (m *RunningModel) HandleEvent(te *runner.TargetEvent) {
 	case parser.EventTestStarted:
 		if state.current != nil {
 			tc := &domain.TestCase{
-				Name:   ev.Name,
-				Suite:  state.current.Name,
-				Status: domain.StatusRunning,
+				Name:     ev.Name,
+				Suite:    state.current.Name,
+				Status:   domain.StatusRunning,
+				Location: ev.Location,
 			}
 			state.current.Tests = append(state.current.Tests, tc)
 		}
@@ -131,6 +144,16 @@ func (m *RunningModel) HandleEvent(te *runner.TargetEvent) {
 				}
 			}
 		}
+
+	case parser.EventTestOutput:
+		if state.current != nil {
+			for _, tc := range state.current.Tests {
+				if tc.Name == ev.Name {
+					tc.Output += ev.Message
+					break
+				}
+			}
+		}
 	}
 }
 
@@ -155,15 +178,17 @@ func (m *RunningModel) BuildAggregatedRun(files []domain.TestFile) *domain.Aggre
 		state := m.targetRuns[targetName]
 
 		// Collect files for this target
+		base, _ := domain.SplitRunName(targetName)
 		var targetFilePaths []string
 		for _, f := range files {
-			if f.TargetName == targetName {
+			if f.TargetName == base {
 				targetFilePaths = append(targetFilePaths, f.Path)
 			}
 		}
 
 		run := &domain.TestRun{
 			TargetName: targetName,
+			TargetType: m.targetTypes[targetName],
 			Suites:     state.suites,
 			Files:      targetFilePaths,
 		}
@@ -189,14 +214,14 @@ func (m *RunningModel) BuildAggregatedRun(files []domain.TestFile) *domain.Aggre
 func (m RunningModel) View(width, height int) string {
 	var lines []string
 
-	lines = append(lines, runningStyle.Render("◉ Running tests..."))
+	lines = append(lines, runningStyle.Render(icons.Running+" Running tests..."))
 	lines = append(lines, "")
 
 	for _, targetName := range m.targetOrder {
 		state := m.targetRuns[targetName]
 
 		// Target header with badge
-		badge := targetBadge(targetName)
+		badge := targetBadge(targetName, m.targetTypes[targetName])
 		status := runningStyle.Render("running")
 		if state.done {
 			if state.errMsg != "" {
@@ -222,11 +247,11 @@ func (m RunningModel) View(width, height int) string {
 		}
 
 		for _, suite := range state.suites {
-			icon := statusStyle(suite.ComputeStatus().Icon()).Render(suite.ComputeStatus().Icon())
+			icon := statusIcon(suite.ComputeStatus())
 			lines = append(lines, fmt.Sprintf("  %s %s", icon, suite.Name))
 
 			for _, tc := range suite.Tests {
-				tcIcon := statusStyle(tc.Status.Icon()).Render(tc.Status.Icon())
+				tcIcon := statusIcon(tc.Status)
 				dur := ""
 				if tc.Duration > 0 {
 					dur = durationStyle.Render(fmt.Sprintf(" %dms", tc.Duration.Milliseconds()))
@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -17,9 +18,14 @@ type SearchModel struct {
 	allFiles []domain.TestFile
 	filtered []matchedFile
 	selected map[string]bool // fileKey → true
+	frecency map[string]int  // fileKey → usage score
+	last     filterResult    // most recently applied filter pass
 	cursor   int
 	width    int
 	height   int
+
+	filterSeq    uint64 // incremented for every query change
+	filterCancel context.CancelFunc
 }
 
 // fileKey returns a unique key for a TestFile.
@@ -35,26 +41,104 @@ func NewSearchModel(files []domain.TestFile) SearchModel {
 	ti.PromptStyle = searchPromptStyle
 	ti.CharLimit = 256
 
-	return SearchModel{
+	m := SearchModel{
 		input:    ti,
 		allFiles: files,
-		filtered: filterFuzzy(files, ""),
 		selected: make(map[string]bool),
 	}
+	m.applyFilter()
+	return m
 }
 
+// SetFiles replaces the file list, e.g. after a rescan. Selections and
+// previous statuses of files that still exist are kept, as is the cursor
+// position when the file under it still matches.
 func (m *SearchModel) SetFiles(files []domain.TestFile) {
+	prevStatus := make(map[string]domain.TestStatus, len(m.allFiles))
+	for _, f := range m.allFiles {
+		prevStatus[fileKey(f)] = f.PrevStatus
+	}
+	var cursorKey string
+	if m.cursor < len(m.filtered) {
+		cursorKey = fileKey(m.filtered[m.cursor].file)
+	}
+
+	selected := make(map[string]bool)
+	for i := range files {
+		k := fileKey(files[i])
+		if status, ok := prevStatus[k]; ok {
+			files[i].PrevStatus = status
+		}
+		if m.selected[k] {
+			selected[k] = true
+		}
+	}
+
 	m.allFiles = files
-	m.selected = make(map[string]bool)
+	m.selected = selected
+	m.applyFilter()
+
+	for i, mf := range m.filtered {
+		if fileKey(mf.file) == cursorKey {
+			m.cursor = i
+			break
+		}
+	}
+}
+
+// SetFrecency replaces the usage scores used to rank files.
+func (m *SearchModel) SetFrecency(frecency map[string]int) {
+	m.frecency = frecency
 	m.applyFilter()
 }
 
+// UpdatePrevStatus sets the previous status of the files in results. It
+// changes a copy of the file list, since a background filter pass may still
+// be reading the current one.
 func (m *SearchModel) UpdatePrevStatus(results map[string]domain.TestStatus) {
-	for i := range m.allFiles {
-		if status, ok := results[m.allFiles[i].Path]; ok {
-			m.allFiles[i].PrevStatus = status
+	files := append([]domain.TestFile(nil), m.allFiles...)
+	for i := range files {
+		if status, ok := results[files[i].Path]; ok {
+			files[i].PrevStatus = status
+		}
+	}
+	m.allFiles = files
+	m.applyFilter()
+}
+
+// Query returns the search query.
+func (m *SearchModel) Query() string {
+	return m.input.Value()
+}
+
+// SelectedEntries returns the toggled files as test set entries, in list
+// order.
+func (m *SearchModel) SelectedEntries() []string {
+	var entries []string
+	for _, f := range m.allFiles {
+		if m.selected[fileKey(f)] {
+			entries = append(entries, f.SetEntry())
+		}
+	}
+	return entries
+}
+
+// ApplySet replaces the query and selection with those of set.
+func (m *SearchModel) ApplySet(set domain.TestSet) {
+	m.selected = make(map[string]bool)
+	for _, f := range m.allFiles {
+		if _, ok := setEntryFor(set, f); ok {
+			m.selected[fileKey(f)] = true
 		}
 	}
+	m.cursor = 0
+	m.SetQuery(set.Query)
+}
+
+// SetQuery replaces the search query.
+func (m *SearchModel) SetQuery(query string) {
+	m.input.SetValue(query)
+	m.input.CursorEnd()
 	m.applyFilter()
 }
 
@@ -143,6 +227,11 @@ func (m *SearchModel) ToggleAll() {
 
 func (m SearchModel) Update(msg tea.Msg) (SearchModel, tea.Cmd) {
 	switch msg := msg.(type) {
+	case filterResultMsg:
+		if msg.seq == m.filterSeq {
+			m.setResult(msg.result)
+		}
+		return m, nil
 	case tea.KeyMsg:
 		switch {
 		case key.Matches(msg, searchKeys.Up):
@@ -168,13 +257,54 @@ func (m SearchModel) Update(msg tea.Msg) (SearchModel, tea.Cmd) {
 	prevValue := m.input.Value()
 	m.input, cmd = m.input.Update(msg)
 	if m.input.Value() != prevValue {
-		m.applyFilter()
+		return m, tea.Batch(cmd, m.queryChanged())
 	}
 	return m, cmd
 }
 
+// applyFilter re-filters the full file list synchronously. It is used when
+// the files or their ranking change, which invalidates any cached candidates.
 func (m *SearchModel) applyFilter() {
-	m.filtered = filterFuzzy(m.allFiles, m.input.Value())
+	m.cancelFilter()
+	res, _ := runFilter(context.Background(), m.allFiles, nil, m.input.Value(), m.frecency)
+	m.setResult(res)
+}
+
+// queryChanged filters for the current input. When the new query narrows
+// the last one, only the previous candidates are re-scored. Small inputs are
+// filtered inline; large ones in the background, cancelling any stale pass.
+func (m *SearchModel) queryChanged() tea.Cmd {
+	m.cancelFilter()
+
+	query := m.input.Value()
+	files, index := m.allFiles, []int(nil)
+	if m.last.candidates != nil && narrows(m.last.terms, parseQuery(query)) {
+		files, index = m.last.candidates, m.last.candidateIndex
+	}
+
+	if len(files) <= syncFilterLimit {
+		res, _ := runFilter(context.Background(), files, index, query, m.frecency)
+		m.setResult(res)
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.filterCancel = cancel
+	return filterCmd(ctx, m.filterSeq, files, index, query, m.frecency)
+}
+
+// cancelFilter abandons any in-flight background filter pass.
+func (m *SearchModel) cancelFilter() {
+	m.filterSeq++
+	if m.filterCancel != nil {
+		m.filterCancel()
+		m.filterCancel = nil
+	}
+}
+
+func (m *SearchModel) setResult(res filterResult) {
+	m.last = res
+	m.filtered = res.matches
 	if m.cursor >= len(m.filtered) {
 		m.cursor = max(0, len(m.filtered)-1)
 	}
@@ -204,7 +334,7 @@ func (m SearchModel) View(width, height int) string {
 		listHeight = 0
 	}
 
-	// Scroll window
+	// Scroll window: only visible rows are rendered and highlighted.
 	start := 0
 	if m.cursor >= listHeight {
 		start = m.cursor - listHeight + 1
@@ -221,40 +351,36 @@ func (m SearchModel) View(width, height int) string {
 		isSelected := m.selected[fileKey(f)]
 		style := normalItemStyle
 		hlStyle := matchHighlightStyle
-		prefix := "  "
+		prefix := strings.Repeat(" ", lipgloss.Width(icons.Cursor)+1)
 		if i == m.cursor {
 			style = selectedItemStyle
 			hlStyle = selectedMatchHighlightStyle
-			prefix = "▸ "
+			prefix = icons.Cursor + " "
 		}
 
 		// Selection marker
-		marker := " "
+		marker := strings.Repeat(" ", lipgloss.Width(icons.Selected))
 		if isSelected {
-			marker = selectedMarkerStyle.Render("◆")
+			marker = selectedMarkerStyle.Render(icons.Selected)
 		}
 
 		// Previous status icon
-		var statusIcon string
-		switch f.PrevStatus {
-		case domain.StatusPassed:
-			statusIcon = passedStyle.Render("✓")
-		case domain.StatusFailed:
-			statusIcon = failedStyle.Render("✗")
-		default:
-			statusIcon = pendingStyle.Render("○")
+		prevIcon := statusIcon(domain.StatusPending)
+		if f.PrevStatus == domain.StatusPassed || f.PrevStatus == domain.StatusFailed {
+			prevIcon = statusIcon(f.PrevStatus)
 		}
 
 		// Target badge
-		badge := targetBadge(f.TargetName)
+		badge := targetBadge(f.TargetName, f.TargetType)
 
-		renderedPath := renderWithHighlight(f.Path, mf.indices, style, hlStyle)
+		_, _, indices := matchTerms(m.last.terms, f, true)
+		renderedPath := renderWithHighlight(f.Path, indices, style, hlStyle)
 		line := fmt.Sprintf("%s%s%s %s", prefix, marker, badge, renderedPath)
-		pad := width - lipgloss.Width(line) - lipgloss.Width(statusIcon) - 2
+		pad := width - lipgloss.Width(line) - lipgloss.Width(prevIcon) - 2
 		if pad < 1 {
 			pad = 1
 		}
-		line = line + strings.Repeat(" ", pad) + statusIcon
+		line = line + strings.Repeat(" ", pad) + prevIcon
 		lines = append(lines, line)
 	}
 
@@ -0,0 +1,231 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/meijin/lazytest/internal/domain"
+)
+
+// SetsModel names a new test set (ModeSaveSet) or picks one to load
+// (ModeSets): the project's sets from .lazytest.yml and the ones saved from
+// the UI, which it owns.
+type SetsModel struct {
+	project []domain.TestSet
+	saved   []domain.TestSet
+	cursor  int
+	naming  bool // asking for the name of the pending set
+	input   textinput.Model
+	pending domain.TestSet // the set being named
+}
+
+func NewSetsModel(project, saved []domain.TestSet) SetsModel {
+	ti := textinput.New()
+	ti.Prompt = "Save as: "
+	ti.PromptStyle = searchPromptStyle
+	ti.CharLimit = 64
+	return SetsModel{project: project, saved: saved, input: ti}
+}
+
+// Sets returns the sets to pick from, by name. Saved sets hide project sets
+// of the same name.
+func (m SetsModel) Sets() []domain.TestSet {
+	sets := append([]domain.TestSet(nil), m.saved...)
+	for _, p := range m.project {
+		if !m.isSaved(p.Name) {
+			sets = append(sets, p)
+		}
+	}
+	sort.Slice(sets, func(i, j int) bool { return sets[i].Name < sets[j].Name })
+	return sets
+}
+
+// Saved returns the sets saved from the UI.
+func (m SetsModel) Saved() []domain.TestSet {
+	return m.saved
+}
+
+func (m SetsModel) isSaved(name string) bool {
+	for _, s := range m.saved {
+		if s.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// StartPicking shows the list of sets.
+func (m *SetsModel) StartPicking() {
+	m.naming = false
+	m.input.Blur()
+	if m.cursor >= len(m.Sets()) {
+		m.cursor = max(0, len(m.Sets())-1)
+	}
+}
+
+// StartNaming asks for the name to save set under.
+func (m *SetsModel) StartNaming(set domain.TestSet) tea.Cmd {
+	m.naming = true
+	m.pending = set
+	m.input.SetValue("")
+	return m.input.Focus()
+}
+
+// Confirm finishes naming: it saves the pending set under the entered name,
+// replacing a saved set of that name, and reports whether it did.
+func (m *SetsModel) Confirm() bool {
+	name := strings.TrimSpace(m.input.Value())
+	if name == "" {
+		return false
+	}
+	set := m.pending
+	set.Name = name
+	m.naming = false
+	m.input.Blur()
+
+	for i, s := range m.saved {
+		if s.Name == name {
+			m.saved[i] = set
+			return true
+		}
+	}
+	m.saved = append(m.saved, set)
+	sort.Slice(m.saved, func(i, j int) bool { return m.saved[i].Name < m.saved[j].Name })
+	return true
+}
+
+// Selected returns the set under the cursor.
+func (m SetsModel) Selected() (domain.TestSet, bool) {
+	sets := m.Sets()
+	if m.cursor >= len(sets) {
+		return domain.TestSet{}, false
+	}
+	return sets[m.cursor], true
+}
+
+// DeleteSelected removes the saved set under the cursor and reports whether
+// there was one. Project sets are only removed by editing .lazytest.yml.
+func (m *SetsModel) DeleteSelected() bool {
+	set, ok := m.Selected()
+	if !ok {
+		return false
+	}
+	for i, s := range m.saved {
+		if s.Name == set.Name {
+			m.saved = append(m.saved[:i], m.saved[i+1:]...)
+			m.StartPicking()
+			return true
+		}
+	}
+	return false
+}
+
+func (m SetsModel) Update(msg tea.Msg) (SetsModel, tea.Cmd) {
+	if m.naming {
+		var cmd tea.Cmd
+		m.input, cmd = m.input.Update(msg)
+		return m, cmd
+	}
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, setsKeys.Up):
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case key.Matches(msg, setsKeys.Down):
+			if m.cursor < len(m.Sets())-1 {
+				m.cursor++
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m SetsModel) View(width, height int) string {
+	if m.naming {
+		summary := fmt.Sprintf("%d file(s)", len(m.pending.Files))
+		if len(m.pending.Files) == 0 {
+			summary = "every file matching the query"
+		}
+		lines := []string{
+			m.input.View(),
+			"",
+			normalItemStyle.Render("  query: ") + detailBodyStyle.Render(m.pending.Query),
+			normalItemStyle.Render("  tests: ") + detailBodyStyle.Render(summary),
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	sets := m.Sets()
+	if len(sets) == 0 {
+		return searchCountStyle.Render("No test sets yet. Select files in search mode and press " +
+			searchKeys.SaveSet.Help().Key + " to save them as one.")
+	}
+
+	start := 0
+	if m.cursor >= height {
+		start = m.cursor - height + 1
+	}
+	var lines []string
+	for i := start; i < len(sets) && i < start+height; i++ {
+		s := sets[i]
+		prefix := strings.Repeat(" ", lipgloss.Width(icons.Cursor)+1)
+		name := suiteNameStyle.Render(s.Name)
+		if i == m.cursor {
+			prefix = icons.Cursor + " "
+			name = selectedItemStyle.Render(s.Name)
+		}
+		detail := fmt.Sprintf("%d file(s)", len(s.Files))
+		if len(s.Files) == 0 {
+			detail = "query only"
+		}
+		if s.Query != "" {
+			detail = fmt.Sprintf("%q, %s", s.Query, detail)
+		}
+		if !m.isSaved(s.Name) {
+			detail += ", from .lazytest.yml"
+		}
+		lines = append(lines, prefix+name+"  "+durationStyle.Render(detail))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ResolveSet returns the files of set among files, in their order: the
+// files it names, or every file its query matches when it names none.
+// missing counts the named files that no longer exist.
+func ResolveSet(set domain.TestSet, files []domain.TestFile) (resolved []domain.TestFile, missing int) {
+	if len(set.Files) == 0 {
+		terms := parseQuery(set.Query)
+		for _, f := range files {
+			if ok, _, _ := matchTerms(terms, f, false); ok {
+				resolved = append(resolved, f)
+			}
+		}
+		return resolved, 0
+	}
+
+	found := make(map[string]bool, len(set.Files))
+	for _, f := range files {
+		if entry, ok := setEntryFor(set, f); ok {
+			resolved = append(resolved, f)
+			found[entry] = true
+		}
+	}
+	return resolved, len(set.Files) - len(found)
+}
+
+// setEntryFor returns the entry of set that names f: its target and path, or
+// a bare path written by hand.
+func setEntryFor(set domain.TestSet, f domain.TestFile) (string, bool) {
+	for _, entry := range set.Files {
+		if entry == f.SetEntry() || entry == f.Path {
+			return entry, true
+		}
+	}
+	return "", false
+}
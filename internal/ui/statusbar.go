@@ -3,6 +3,7 @@ package ui
 import (
 	"fmt"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/meijin/lazytest/internal/domain"
 )
@@ -13,52 +14,54 @@ func renderStatusBar(run *domain.AggregatedRun, width int) string {
 	}
 
 	stats := fmt.Sprintf(
-		"%s %d  %s %d  %s %d  ⏱ %s",
-		passedStyle.Render("✓"),
+		"%s %d  %s %d  %s %d  %s %s",
+		passedStyle.Render(icons.Passed),
 		run.Passed,
-		failedStyle.Render("✗"),
+		failedStyle.Render(icons.Failed),
 		run.Failed,
-		skippedStyle.Render("⊘"),
+		skippedStyle.Render(icons.Skipped),
 		run.Skipped,
+		icons.Duration,
 		run.Duration.Round(100*1e6), // round to 100ms
 	)
 
 	return statusBarStyle.Width(width).Render(stats)
 }
 
-func renderHelpBar(mode Mode, width int) string {
+// renderHelpBar lists the bindings of mode. extra are the results mode
+// bindings that depend on the run and the config, like actions.
+func renderHelpBar(mode Mode, extra []key.Binding, width int) string {
 	var items []string
+	var bindings []key.Binding
 
 	switch mode {
 	case ModeSearch:
-		items = []string{
-			helpKeyStyle.Render("[Tab]") + " " + helpDescStyle.Render("select"),
-			helpKeyStyle.Render("[Ctrl+A]") + " " + helpDescStyle.Render("select all"),
-			helpKeyStyle.Render("[Enter]") + " " + helpDescStyle.Render("run"),
-			helpKeyStyle.Render("[Ctrl+C]") + " " + helpDescStyle.Render("quit"),
-		}
+		bindings = []key.Binding{searchKeys.Toggle, searchKeys.SelectAll, searchKeys.Run, searchKeys.SaveSet, searchKeys.Sets, searchKeys.Rescan, searchKeys.Quit}
+	case ModeSets:
+		bindings = []key.Binding{setsKeys.Load, setsKeys.Delete, setsKeys.Close, setsKeys.Quit}
+	case ModeSaveSet:
+		bindings = []key.Binding{setsKeys.Save, setsKeys.Close, setsKeys.Quit}
 	case ModeRunning:
-		items = []string{
-			helpDescStyle.Render("Running tests..."),
-			helpKeyStyle.Render("[Esc]") + " " + helpDescStyle.Render("cancel"),
-			helpKeyStyle.Render("[Ctrl+C]") + " " + helpDescStyle.Render("quit"),
-		}
+		items = append(items, helpDescStyle.Render("Running tests..."))
+		bindings = []key.Binding{runningKeys.Cancel, runningKeys.Quit}
 	case ModeResults:
-		items = []string{
-			helpKeyStyle.Render("[Enter]") + " " + helpDescStyle.Render("search"),
-			helpKeyStyle.Render("[o]") + " " + helpDescStyle.Render("open"),
-			helpKeyStyle.Render("[r]") + " " + helpDescStyle.Render("rerun"),
-			helpKeyStyle.Render("[R]") + " " + helpDescStyle.Render("rerun all"),
-			helpKeyStyle.Render("[f]") + " " + helpDescStyle.Render("fails"),
-			helpKeyStyle.Render("[l]") + " " + helpDescStyle.Render("detail"),
-			helpKeyStyle.Render("[q]") + " " + helpDescStyle.Render("quit"),
-		}
+		bindings = []key.Binding{resultsKeys.Enter, resultsKeys.Open, resultsKeys.Rerun, resultsKeys.RerunAll, resultsKeys.Filter, resultsKeys.Right}
+		bindings = append(bindings, extra...)
+		bindings = append(bindings, resultsKeys.Quit)
+	}
+	for _, b := range bindings {
+		items = append(items, helpItem(b))
 	}
 
 	line := lipgloss.JoinHorizontal(lipgloss.Left, joinWithSep(items, "  ")...)
 	return statusBarStyle.Width(width).Render(line)
 }
 
+// helpItem renders b as "[key] description".
+func helpItem(b key.Binding) string {
+	return helpKeyStyle.Render("["+b.Help().Key+"]") + " " + helpDescStyle.Render(b.Help().Desc)
+}
+
 func joinWithSep(items []string, sep string) []string {
 	if len(items) == 0 {
 		return nil
@@ -1,160 +1,414 @@
 package ui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"os"
+	"strings"
 
-var (
-	// Colors
-	colorPrimary   = lipgloss.Color("#7C3AED") // purple
-	colorSuccess   = lipgloss.Color("#22C55E") // green
-	colorDanger    = lipgloss.Color("#EF4444") // red
-	colorWarning   = lipgloss.Color("#F59E0B") // yellow
-	colorMuted     = lipgloss.Color("#6B7280") // gray
-	colorHighlight = lipgloss.Color("#E0E7FF") // light purple
-	colorBg        = lipgloss.Color("#1E1E2E") // dark bg
-	colorBorder    = lipgloss.Color("#4B5563") // border gray
-
-	// Target badge colors
-	colorPHPUnit = lipgloss.Color("#4F5B93") // blue-ish (PHP)
-	colorVitest  = lipgloss.Color("#729B1B") // green-ish (Vitest)
-	colorJest    = lipgloss.Color("#C63D14") // red-ish (Jest)
+	"github.com/charmbracelet/lipgloss"
+	"github.com/meijin/lazytest/internal/config"
+	"github.com/meijin/lazytest/internal/domain"
+)
+
+// palette is the set of colors a theme builds the styles from.
+type palette struct {
+	primary       lipgloss.TerminalColor // titles, prompt, active border
+	success       lipgloss.TerminalColor
+	danger        lipgloss.TerminalColor
+	warning       lipgloss.TerminalColor
+	muted         lipgloss.TerminalColor // secondary text
+	highlight     lipgloss.TerminalColor // selected row
+	border        lipgloss.TerminalColor
+	text          lipgloss.TerminalColor // file and test names
+	strongText    lipgloss.TerminalColor // suite names
+	detailText    lipgloss.TerminalColor // failure output
+	match         lipgloss.TerminalColor // fuzzy match highlights
+	selectedMatch lipgloss.TerminalColor // fuzzy match highlights in the selected row
+	badgeText     lipgloss.TerminalColor
+	badges        map[string]lipgloss.TerminalColor // badge backgrounds by target type
+}
+
+// Target badge colors, shared by the themes.
+var badgeColors = map[string]lipgloss.TerminalColor{
+	"phpunit": lipgloss.Color("#4F5B93"), // blue-ish (PHP)
+	"vitest":  lipgloss.Color("#729B1B"), // green-ish (Vitest)
+	"jest":    lipgloss.Color("#C63D14"), // red-ish (Jest)
+	"pytest":  lipgloss.Color("#0A9EDC"), // light blue (pytest)
+	"go":      lipgloss.Color("#00ADD8"), // cyan (Go)
+	"cargo":   lipgloss.Color("#B7410E"), // rust
+	"rspec":   lipgloss.Color("#CC342D"), // ruby red
+	"bun":     lipgloss.Color("#7A5C3E"), // brown (Bun)
+	"deno":    lipgloss.Color("#2E2E2E"), // near black (Deno)
+	"pest":    lipgloss.Color("#C026D3"), // magenta (Pest)
+}
+
+// themes are the built-in palettes, by config.Themes name. "auto" resolves
+// to dark or light.
+var themes = map[string]palette{
+	"dark": {
+		primary:       lipgloss.Color("#7C3AED"), // purple
+		success:       lipgloss.Color("#22C55E"), // green
+		danger:        lipgloss.Color("#EF4444"), // red
+		warning:       lipgloss.Color("#F59E0B"), // yellow
+		muted:         lipgloss.Color("#6B7280"), // gray
+		highlight:     lipgloss.Color("#E0E7FF"), // light purple
+		border:        lipgloss.Color("#4B5563"), // border gray
+		text:          lipgloss.Color("#C9D1D9"), // light gray, readable on dark bg
+		strongText:    lipgloss.Color("#E5E7EB"),
+		detailText:    lipgloss.Color("#D1D5DB"),
+		match:         lipgloss.Color("#F59E0B"), // amber
+		selectedMatch: lipgloss.Color("#FCD34D"), // lighter amber for selected row
+		badgeText:     lipgloss.Color("#FFFFFF"),
+		badges:        badgeColors,
+	},
+	"light": {
+		primary:       lipgloss.Color("#6D28D9"), // deep purple
+		success:       lipgloss.Color("#15803D"), // dark green
+		danger:        lipgloss.Color("#B91C1C"), // dark red
+		warning:       lipgloss.Color("#B45309"), // dark amber
+		muted:         lipgloss.Color("#57606A"), // gray, readable on white
+		highlight:     lipgloss.Color("#4C1D95"), // dark purple
+		border:        lipgloss.Color("#D1D5DB"), // light border gray
+		text:          lipgloss.Color("#24292F"), // near black
+		strongText:    lipgloss.Color("#111827"),
+		detailText:    lipgloss.Color("#374151"),
+		match:         lipgloss.Color("#C2410C"), // orange
+		selectedMatch: lipgloss.Color("#9A3412"), // darker orange for selected row
+		badgeText:     lipgloss.Color("#FFFFFF"),
+		badges:        badgeColors,
+	},
+	// Pure colors at full contrast with either background.
+	"high-contrast": {
+		primary:       lipgloss.AdaptiveColor{Light: "#0000EE", Dark: "#FFFF00"},
+		success:       lipgloss.AdaptiveColor{Light: "#006400", Dark: "#00FF00"},
+		danger:        lipgloss.AdaptiveColor{Light: "#CC0000", Dark: "#FF5555"},
+		warning:       lipgloss.AdaptiveColor{Light: "#8B4500", Dark: "#FFB000"},
+		muted:         lipgloss.AdaptiveColor{Light: "#000000", Dark: "#FFFFFF"},
+		highlight:     lipgloss.AdaptiveColor{Light: "#000000", Dark: "#FFFFFF"},
+		border:        lipgloss.AdaptiveColor{Light: "#000000", Dark: "#FFFFFF"},
+		text:          lipgloss.AdaptiveColor{Light: "#000000", Dark: "#FFFFFF"},
+		strongText:    lipgloss.AdaptiveColor{Light: "#000000", Dark: "#FFFFFF"},
+		detailText:    lipgloss.AdaptiveColor{Light: "#000000", Dark: "#FFFFFF"},
+		match:         lipgloss.AdaptiveColor{Light: "#0000EE", Dark: "#00FFFF"},
+		selectedMatch: lipgloss.AdaptiveColor{Light: "#0000EE", Dark: "#00FFFF"},
+		badgeText:     lipgloss.AdaptiveColor{Light: "#FFFFFF", Dark: "#000000"},
+		badges:        nil, // all badges in the text color
+	},
+	// Okabe-Ito colors, which stay distinct with red-green color blindness:
+	// passed is blue and failed vermillion.
+	"colorblind": {
+		primary:       lipgloss.Color("#CC79A7"),                                 // reddish purple
+		success:       lipgloss.AdaptiveColor{Light: "#0072B2", Dark: "#56B4E9"}, // blue
+		danger:        lipgloss.Color("#D55E00"),                                 // vermillion
+		warning:       lipgloss.AdaptiveColor{Light: "#9A6700", Dark: "#F0E442"}, // yellow
+		muted:         lipgloss.AdaptiveColor{Light: "#57606A", Dark: "#8B949E"},
+		highlight:     lipgloss.AdaptiveColor{Light: "#000000", Dark: "#FFFFFF"},
+		border:        lipgloss.AdaptiveColor{Light: "#D1D5DB", Dark: "#4B5563"},
+		text:          lipgloss.AdaptiveColor{Light: "#24292F", Dark: "#C9D1D9"},
+		strongText:    lipgloss.AdaptiveColor{Light: "#111827", Dark: "#E5E7EB"},
+		detailText:    lipgloss.AdaptiveColor{Light: "#374151", Dark: "#D1D5DB"},
+		match:         lipgloss.Color("#E69F00"), // orange
+		selectedMatch: lipgloss.AdaptiveColor{Light: "#E69F00", Dark: "#F0E442"},
+		badgeText:     lipgloss.Color("#FFFFFF"),
+		badges:        badgeColors,
+	},
+}
+
+// noColor is the palette used when NO_COLOR is set: the terminal's default
+// colors, with bold and underline left to tell things apart.
+var noColor = palette{
+	primary: lipgloss.NoColor{}, success: lipgloss.NoColor{}, danger: lipgloss.NoColor{},
+	warning: lipgloss.NoColor{}, muted: lipgloss.NoColor{}, highlight: lipgloss.NoColor{},
+	border: lipgloss.NoColor{}, text: lipgloss.NoColor{}, strongText: lipgloss.NoColor{},
+	detailText: lipgloss.NoColor{}, match: lipgloss.NoColor{}, selectedMatch: lipgloss.NoColor{},
+	badgeText: lipgloss.NoColor{},
+}
 
+var (
 	// Box styles
+	boxStyle       lipgloss.Style
+	activeBoxStyle lipgloss.Style
+
+	// Title styles
+	titleStyle lipgloss.Style
+
+	// Status bar
+	statusBarStyle lipgloss.Style
+
+	// Test status icons
+	passedStyle  lipgloss.Style
+	failedStyle  lipgloss.Style
+	pendingStyle lipgloss.Style
+	runningStyle lipgloss.Style
+	skippedStyle lipgloss.Style
+
+	// List items
+	selectedItemStyle lipgloss.Style
+	normalItemStyle   lipgloss.Style
+	suiteNameStyle    lipgloss.Style
+	testNameStyle     lipgloss.Style
+
+	// Detail pane
+	detailTitleStyle lipgloss.Style
+	detailBodyStyle  lipgloss.Style
+
+	// Search input
+	searchPromptStyle lipgloss.Style
+	searchCountStyle  lipgloss.Style
+
+	// Fuzzy match highlights
+	matchHighlightStyle         lipgloss.Style
+	selectedMatchHighlightStyle lipgloss.Style
+
+	// Selection marker
+	selectedMarkerStyle lipgloss.Style
+
+	// Help bar
+	helpKeyStyle  lipgloss.Style
+	helpDescStyle lipgloss.Style
+
+	// Duration
+	durationStyle lipgloss.Style
+
+	// Target badges, by target type
+	badgeStyles       map[string]lipgloss.Style
+	defaultBadgeStyle lipgloss.Style
+)
+
+func init() {
+	applyPalette(themes["dark"])
+}
+
+// applyTheme builds the styles from the named theme. NO_COLOR, when set,
+// wins over any theme.
+func applyTheme(name string) {
+	switch {
+	case os.Getenv("NO_COLOR") != "":
+		applyPalette(noColor)
+		// Without colors, failures and the selected match need another cue.
+		failedStyle = failedStyle.Bold(true)
+		selectedMatchHighlightStyle = selectedMatchHighlightStyle.Underline(true)
+	case name == "" || name == "auto":
+		if lipgloss.HasDarkBackground() {
+			applyPalette(themes["dark"])
+		} else {
+			applyPalette(themes["light"])
+		}
+	default:
+		if p, ok := themes[name]; ok {
+			applyPalette(p)
+		}
+	}
+}
+
+// applyPalette builds every style from the colors of p.
+func applyPalette(p palette) {
 	boxStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(colorBorder)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(p.border)
 
 	activeBoxStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(colorPrimary)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(p.primary)
 
-	// Title styles
 	titleStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(colorPrimary).
-			Padding(0, 1)
+		Bold(true).
+		Foreground(p.primary).
+		Padding(0, 1)
 
-	// Status bar
 	statusBarStyle = lipgloss.NewStyle().
-			Foreground(colorMuted).
-			Padding(0, 1)
+		Foreground(p.muted).
+		Padding(0, 1)
 
-	// Test status icons
-	passedStyle = lipgloss.NewStyle().Foreground(colorSuccess)
-	failedStyle = lipgloss.NewStyle().Foreground(colorDanger)
-	pendingStyle = lipgloss.NewStyle().Foreground(colorMuted)
-	runningStyle = lipgloss.NewStyle().Foreground(colorWarning)
-	skippedStyle = lipgloss.NewStyle().Foreground(colorWarning)
+	passedStyle = lipgloss.NewStyle().Foreground(p.success)
+	failedStyle = lipgloss.NewStyle().Foreground(p.danger)
+	pendingStyle = lipgloss.NewStyle().Foreground(p.muted)
+	runningStyle = lipgloss.NewStyle().Foreground(p.warning)
+	skippedStyle = lipgloss.NewStyle().Foreground(p.warning)
 
-	// List items
 	selectedItemStyle = lipgloss.NewStyle().
-				Foreground(colorHighlight).
-				Bold(true)
+		Foreground(p.highlight).
+		Bold(true)
 
 	normalItemStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#C9D1D9")) // light gray, readable on dark bg
+		Foreground(p.text)
 
 	suiteNameStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#E5E7EB")).
-			Bold(true)
+		Foreground(p.strongText).
+		Bold(true)
 
 	testNameStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#C9D1D9"))
+		Foreground(p.text)
 
-	// Detail pane
 	detailTitleStyle = lipgloss.NewStyle().
-				Bold(true).
-				Foreground(colorDanger).
-				MarginBottom(1)
+		Bold(true).
+		Foreground(p.danger).
+		MarginBottom(1)
 
 	detailBodyStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#D1D5DB"))
+		Foreground(p.detailText)
 
-	// Search input
 	searchPromptStyle = lipgloss.NewStyle().
-				Foreground(colorPrimary).
-				Bold(true)
+		Foreground(p.primary).
+		Bold(true)
 
 	searchCountStyle = lipgloss.NewStyle().
-				Foreground(colorMuted)
+		Foreground(p.muted)
 
-	// Fuzzy match highlights
 	matchHighlightStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#F59E0B")). // amber
-				Bold(true)
+		Foreground(p.match).
+		Bold(true)
 
 	selectedMatchHighlightStyle = lipgloss.NewStyle().
-					Foreground(lipgloss.Color("#FCD34D")). // lighter amber for selected row
-					Bold(true)
+		Foreground(p.selectedMatch).
+		Bold(true)
 
-	// Selection marker
 	selectedMarkerStyle = lipgloss.NewStyle().
-				Foreground(colorPrimary).
-				Bold(true)
+		Foreground(p.primary).
+		Bold(true)
 
-	// Help bar
 	helpKeyStyle = lipgloss.NewStyle().
-			Foreground(colorPrimary).
-			Bold(true)
+		Foreground(p.primary).
+		Bold(true)
 
 	helpDescStyle = lipgloss.NewStyle().
-			Foreground(colorMuted)
+		Foreground(p.muted)
 
-	// Duration
 	durationStyle = lipgloss.NewStyle().
-			Foreground(colorMuted)
-
-	// Target badge styles
-	phpunitBadgeStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#FFFFFF")).
-				Background(colorPHPUnit).
-				Bold(true).
-				Padding(0, 1)
-
-	vitestBadgeStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#FFFFFF")).
-				Background(colorVitest).
-				Bold(true).
-				Padding(0, 1)
-
-	jestBadgeStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#FFFFFF")).
-			Background(colorJest).
-			Bold(true).
-			Padding(0, 1)
-)
+		Foreground(p.muted)
 
-func statusStyle(icon string) lipgloss.Style {
-	switch icon {
-	case "✓":
-		return passedStyle
-	case "✗":
-		return failedStyle
-	case "○":
-		return pendingStyle
-	case "◉":
-		return runningStyle
-	case "⊘":
-		return skippedStyle
-	default:
-		return pendingStyle
+	badgeStyles = make(map[string]lipgloss.Style, len(p.badges))
+	for typ, bg := range p.badges {
+		badgeStyles[typ] = badgeStyle(p.badgeText, bg)
+	}
+	defaultBadgeStyle = badgeStyle(p.badgeText, p.muted)
+	if p.badges == nil {
+		defaultBadgeStyle = badgeStyle(p.badgeText, p.text)
+	}
+}
+
+// badgeStyle returns a target badge style with the given colors.
+func badgeStyle(fg, bg lipgloss.TerminalColor) lipgloss.Style {
+	return lipgloss.NewStyle().
+		Foreground(fg).
+		Background(bg).
+		Bold(true).
+		Padding(0, 1)
+}
+
+// iconSets are the built-in status symbols, by config.IconSets name.
+var iconSets = map[string]config.Icons{
+	"unicode": {Passed: "✓", Failed: "✗", Skipped: "⊘", Pending: "○", Running: "◉", Cursor: "▸", Selected: "◆", Duration: "⏱"},
+	"ascii":   {Passed: "+", Failed: "x", Skipped: "-", Pending: ".", Running: "*", Cursor: ">", Selected: "#", Duration: "time"},
+}
+
+// icons are the status symbols in use.
+var icons = iconSets["unicode"]
+
+// applyIcons selects the icon set of ic and replaces the symbols it sets.
+func applyIcons(ic config.Icons) {
+	set, ok := iconSets[ic.Set]
+	if !ok {
+		set = iconSets["unicode"]
 	}
+	ic.Set = ""
+	icons = ic.Merge(set)
 }
 
-// targetBadge returns a styled badge string for the given target name.
-func targetBadge(name string) string {
-	switch name {
-	case "phpunit":
-		return phpunitBadgeStyle.Render("PHP")
-	case "vitest":
-		return vitestBadgeStyle.Render("VT")
-	case "jest":
-		return jestBadgeStyle.Render("JT")
+// statusIcon returns the styled symbol for status s.
+func statusIcon(s domain.TestStatus) string {
+	switch s {
+	case domain.StatusPassed:
+		return passedStyle.Render(icons.Passed)
+	case domain.StatusFailed:
+		return failedStyle.Render(icons.Failed)
+	case domain.StatusSkipped:
+		return skippedStyle.Render(icons.Skipped)
+	case domain.StatusRunning:
+		return runningStyle.Render(icons.Running)
 	default:
-		return lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#FFFFFF")).
-			Background(colorMuted).
-			Bold(true).
-			Padding(0, 1).
-			Render(name)
+		return pendingStyle.Render(icons.Pending)
+	}
+}
+
+// badgeLabels are the short names target badges show, by target type.
+var badgeLabels = map[string]string{
+	"phpunit": "PHP", "vitest": "VT", "jest": "JT", "pytest": "PY",
+	"go": "GO", "cargo": "RS", "rspec": "RB", "bun": "BUN", "deno": "DENO", "pest": "PEST",
+}
+
+// targetBadge returns a styled badge string for a target of the given type.
+// Targets named after their type show just the type's badge; package
+// targets ("vitest:web") add the package and other names are added whole.
+func targetBadge(name, typ string) string {
+	label, ok := badgeLabels[typ]
+	if !ok {
+		return defaultBadgeStyle.Render(name)
+	}
+	if name != typ {
+		label += ":" + strings.TrimPrefix(name, typ+":")
+	}
+	if st, ok := badgeStyles[typ]; ok {
+		return st.Render(label)
+	}
+	return defaultBadgeStyle.Render(label)
+}
+
+// applyStyles applies the overrides in s on top of the theme's styles.
+func applyStyles(s config.Styles) {
+	for _, o := range []struct {
+		style    *lipgloss.Style
+		override config.Style
+	}{
+		{&titleStyle, s.Title},
+		{&boxStyle, s.Border},
+		{&activeBoxStyle, s.ActiveBorder},
+		{&statusBarStyle, s.StatusBar},
+		{&passedStyle, s.Passed},
+		{&failedStyle, s.Failed},
+		{&skippedStyle, s.Skipped},
+		{&runningStyle, s.Running},
+		{&pendingStyle, s.Pending},
+		{&normalItemStyle, s.Item},
+		{&selectedItemStyle, s.SelectedItem},
+		{&selectedMarkerStyle, s.SelectedMarker},
+		{&matchHighlightStyle, s.MatchHighlight},
+		{&selectedMatchHighlightStyle, s.SelectedMatchHighlight},
+		{&suiteNameStyle, s.SuiteName},
+		{&testNameStyle, s.TestName},
+		{&detailTitleStyle, s.DetailTitle},
+		{&detailBodyStyle, s.DetailBody},
+		{&searchPromptStyle, s.SearchPrompt},
+		{&searchCountStyle, s.SearchCount},
+		{&helpKeyStyle, s.HelpKey},
+		{&helpDescStyle, s.HelpDesc},
+		{&durationStyle, s.Duration},
+	} {
+		*o.style = overrideStyle(*o.style, o.override)
+	}
+}
+
+// overrideStyle returns st with the attributes o sets replaced.
+func overrideStyle(st lipgloss.Style, o config.Style) lipgloss.Style {
+	if o.Foreground != "" {
+		st = st.Foreground(lipgloss.Color(o.Foreground))
+	}
+	if o.Background != "" {
+		st = st.Background(lipgloss.Color(o.Background))
+	}
+	if o.BorderColor != "" {
+		st = st.BorderForeground(lipgloss.Color(o.BorderColor))
+	}
+	if o.Bold != nil {
+		st = st.Bold(*o.Bold)
+	}
+	if o.Italic != nil {
+		st = st.Italic(*o.Italic)
+	}
+	if o.Underline != nil {
+		st = st.Underline(*o.Underline)
+	}
+	if o.Faint != nil {
+		st = st.Faint(*o.Faint)
 	}
+	return st
 }